@@ -72,7 +72,7 @@ func (m textInputModel) View() string {
 func initRestlerProject() error {
 	p := tea.NewProgram(initialTextInputModel())
 	if _, err := p.Run(); err != nil {
-		fmt.Println("Error occurred while initializing restler project: ", err)
+		fmt.Fprintln(os.Stderr, "Error occurred while initializing restler project: ", err)
 		return err
 	}
 	return nil
@@ -82,21 +82,21 @@ func initRestlerProject() error {
 func executeInitCommand(path string) error {
 	// if path exists, thats it, otherwise ask if user wants to create it
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		fmt.Println("[log]: Path doesn't exist, creating restler project in: ", path)
+		fmt.Fprintln(os.Stderr, "[log]: Path doesn't exist, creating restler project in: ", path)
 		err := os.MkdirAll(path, 0755)
 		if err != nil {
-			fmt.Println("[error]: Error occurred while creating restler project: ", err)
+			fmt.Fprintln(os.Stderr, "[error]: Error occurred while creating restler project: ", err)
 			return err
 		}
 		err = svc.CreateDefaultFile(path)
 		if err != nil {
-			fmt.Println("[error]: Error occurred while creating default files: ", err)
+			fmt.Fprintln(os.Stderr, "[error]: Error occurred while creating default files: ", err)
 			return err
 		}
 		env.UpdateEnv(path)
 		return nil
 	} else {
-		fmt.Println("[info]: path exists, updating RESTLER_PATH env: ")
+		fmt.Fprintln(os.Stderr, "[info]: path exists, updating RESTLER_PATH env: ")
 		env.UpdateEnv(path)
 		return nil
 	}
@@ -1,14 +1,9 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
-	"net/http"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -17,7 +12,6 @@ import (
 	"github.com/shrijan00003/restler/core/env"
 	"github.com/shrijan00003/restler/core/logger"
 	"github.com/shrijan00003/restler/core/utils"
-	"gopkg.in/yaml.v3"
 
 	"github.com/urfave/cli/v2"
 )
@@ -45,10 +39,11 @@ var restlerPath string
 var a *app.App
 
 func main() {
-	defer func() {
-		a.Terminate()
-	}()
-	run()
+	exitCode := run()
+	a.Terminate()
+	env.Terminate()
+	logger.Terminate()
+	os.Exit(exitCode)
 }
 
 // -------------------------
@@ -57,11 +52,6 @@ func main() {
 // initialize restler project
 // -------------------------
 func initialize() {
-	defer func() {
-		env.Terminate()
-		logger.Terminate()
-	}()
-
 	logger.Init()
 
 	// load config.yaml file in the root of restler project
@@ -70,31 +60,219 @@ func initialize() {
 
 	// Load default env with .env and .env.local
 	env.LoadEnv(pConfig)
-	proxyURL := svc.GetProxyURL()
-	a = app.NewApp(proxyURL, APP_VERSION, pConfig)
+	a = app.NewApp(APP_VERSION, pConfig)
 }
 
-func run() {
+func run() int {
 	initialize()
 	app := &cli.App{
 		Name:    "Restler Application",
 		Usage:   "Developer friendly rest client for developers only!!",
 		Version: APP_VERSION,
+		Flags:   []cli.Flag{errorFormatFlag()},
+		Before:  setErrorFormat,
+		// errors are written with --error-format and exit code of their kind
+		ExitErrHandler: func(*cli.Context, error) {},
+		OnUsageError:   usageError,
 		Commands: []*cli.Command{
 			{
-				Name:    "run",
-				Aliases: []string{"r"},
-				Usage:   "Run request",
+				Name:         "run",
+				Aliases:      []string{"r"},
+				Usage:        "Run request",
+				OnUsageError: usageError,
+				Before:       setErrorFormat,
+				Flags: []cli.Flag{
+					errorFormatFlag(),
+					&cli.Int64Flag{
+						Name:  "seed",
+						Usage: "seed for random dynamic variables like {{$randomInt}} to get reproducible values",
+					},
+					&cli.BoolFlag{
+						Name:  "keep-raw",
+						Usage: "save raw compressed response body next to the response file",
+					},
+					&cli.BoolFlag{
+						Name:  "allow-undefined",
+						Usage: "warn instead of failing when a variable used in the request is not defined",
+					},
+					&cli.BoolFlag{
+						Name:  "bare-vars",
+						Usage: "also expand $VAR and $$ in the request like a shell",
+					},
+					&cli.BoolFlag{
+						Name:  "raw",
+						Usage: "write response body as it is sent by the server instead of pretty printing it",
+					},
+					&cli.BoolFlag{
+						Name:  "sort-keys",
+						Usage: "sort keys of json objects in the pretty printed response body",
+					},
+					&cli.StringSliceFlag{
+						Name:  "format",
+						Usage: "formats of the response files, one or more of markdown, json, yaml, har, http",
+					},
+					&cli.StringFlag{
+						Name:    "print",
+						Aliases: []string{"p"},
+						Usage:   "print response to stdout, one of body, headers (headers and body) or report (first format)",
+					},
+					&cli.BoolFlag{
+						Name:  "no-save",
+						Usage: "don't write the response files",
+					},
+					&cli.BoolFlag{
+						Name:  "status-exit",
+						Usage: "exit with 3, 4 or 5 for 3xx, 4xx or 5xx response status",
+					},
+				},
 				Action: func(cCtx *cli.Context) error {
 					return runAction(cCtx)
 				},
 			},
+			{
+				Name:         "validate",
+				Usage:        "Validate request files, directories are checked recursively",
+				ArgsUsage:    "<path...>",
+				OnUsageError: usageError,
+				Before:       setErrorFormat,
+				Flags: []cli.Flag{
+					errorFormatFlag(),
+					&cli.BoolFlag{
+						Name:  "allow-undefined",
+						Usage: "warn instead of failing when a variable used in the request is not defined",
+					},
+				},
+				Action: validateAction,
+			},
+			{
+				Name:         "test",
+				Aliases:      []string{"t"},
+				Usage:        "Run request files of the paths and check their responses, directories are searched recursively",
+				ArgsUsage:    "[path...]",
+				OnUsageError: usageError,
+				Before:       setErrorFormat,
+				Flags: []cli.Flag{
+					errorFormatFlag(),
+					&cli.StringSliceFlag{
+						Name:  "include",
+						Usage: "run only the request files matching the glob eg. *.get.yaml or users/**",
+					},
+					&cli.StringSliceFlag{
+						Name:  "exclude",
+						Usage: "skip the request files matching the glob",
+					},
+					&cli.StringSliceFlag{
+						Name:  "tag",
+						Usage: "run only the requests with any of the tags",
+					},
+					&cli.StringSliceFlag{
+						Name:  "exclude-tag",
+						Usage: "skip the requests with any of the tags",
+					},
+					&cli.IntFlag{
+						Name:    "parallel",
+						Aliases: []string{"j"},
+						Value:   1,
+						Usage:   "number of requests running at the same time, requests run in order of the files by default",
+					},
+					&cli.StringFlag{
+						Name:  "reporter",
+						Value: svc.DefaultTestReporter,
+						Usage: "report of the results, one of table, junit or tap",
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "write the report to the file, table is printed to stdout as well",
+					},
+					&cli.Int64Flag{
+						Name:  "seed",
+						Usage: "seed for random dynamic variables like {{$randomInt}} to get reproducible values",
+					},
+					&cli.BoolFlag{
+						Name:  "allow-undefined",
+						Usage: "warn instead of failing when a variable used in the request is not defined",
+					},
+					&cli.BoolFlag{
+						Name:  "bare-vars",
+						Usage: "also expand $VAR and $$ in the request like a shell",
+					},
+					&cli.StringSliceFlag{
+						Name:  "format",
+						Usage: "formats of the response files, one or more of markdown, json, yaml, har, http",
+					},
+					&cli.BoolFlag{
+						Name:  "no-save",
+						Usage: "don't write the response files",
+					},
+				},
+				Action: testAction,
+			},
+			{
+				Name:  "cookies",
+				Usage: "Manage cookies of the current env",
+				Subcommands: []*cli.Command{
+					{
+						Name:    "list",
+						Aliases: []string{"ls"},
+						Usage:   "List stored cookies",
+						Action:  cookiesListAction,
+					},
+					{
+						Name:   "clear",
+						Usage:  "Delete all stored cookies",
+						Action: cookiesClearAction,
+					},
+					{
+						Name:      "delete",
+						Aliases:   []string{"rm"},
+						Usage:     "Delete cookies by name",
+						ArgsUsage: "<name>",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "domain",
+								Usage: "delete the cookie only for this domain",
+							},
+						},
+						Action: cookiesDeleteAction,
+					},
+				},
+			},
 		},
 	}
 
 	if err := app.Run(os.Args); err != nil {
-		log.Fatal(err)
+		svc.WriteError(os.Stderr, err, errorFormat)
+		return svc.ExitCode(err)
 	}
+	return 0
+}
+
+// errorFormat is set with --error-format flag, it can be used before or after
+// the command eg. restler --error-format json run request.yaml
+var errorFormat = svc.ErrorFormatText
+
+func errorFormatFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "error-format",
+		Usage: "format of the errors written to stderr, text or json (default: text)",
+	}
+}
+
+func setErrorFormat(cCtx *cli.Context) error {
+	switch format := cCtx.String("error-format"); format {
+	case "":
+	case svc.ErrorFormatText, svc.ErrorFormatJSON:
+		errorFormat = format
+	default:
+		return svc.Errorf(svc.ErrorKindUsage, "unknown error format %s, use text or json", format)
+	}
+	return nil
+}
+
+// usageError marks invalid flags as usage error to exit with its exit code
+func usageError(cCtx *cli.Context, err error, isSubcommand bool) error {
+	return svc.NewError(svc.ErrorKindUsage, err)
 }
 
 type ActionName string
@@ -113,160 +291,273 @@ func runAction(cCtx *cli.Context) error {
 	var reqPath = cCtx.Args().First()
 
 	if reqPath == "" {
-		log.Fatal("[Resterl Error]: Please provide request args like collection/request-name.yaml")
+		return svc.Errorf(svc.ErrorKindUsage, "Please provide request args like collection/request-name.yaml")
 	}
 
 	if _, err := os.Stat(reqPath); os.IsNotExist(err) {
-		log.Fatal("[Restler Error]: Request not found in path: ", reqPath)
+		return &svc.Error{Kind: svc.ErrorKindFile, Path: reqPath, Err: errors.New("Request not found")}
 	}
 
-	pReq, err := svc.ParseRequest(reqPath)
-	if err != nil {
-		logger.Debug("error processing request:", err)
-		log.Fatal("[restler Error]: Error processing your request, make sure you have valid format")
+	if cCtx.IsSet("seed") {
+		svc.SetSeed(cCtx.Int64("seed"))
 	}
+	svc.SetAllowUndefined(cCtx.Bool("allow-undefined"))
+	svc.SetBareVars(cCtx.Bool("bare-vars"))
 
-	pRes, err := svc.ProcessRequest(pReq, a)
+	formats := cCtx.StringSlice("format")
+	if len(formats) == 0 && a.Config != nil {
+		formats = a.Config.Formats
+	}
+	formatters, err := svc.GetFormatters(formats)
 	if err != nil {
-		log.Fatal("[restler Error]: Error processing your request: ", err)
+		return svc.NewError(svc.ErrorKindUsage, err)
+	}
+	printMode := cCtx.String("print")
+	switch printMode {
+	case "", svc.PrintBody, svc.PrintHeaders, svc.PrintReport:
+	default:
+		return svc.Errorf(svc.ErrorKindUsage, "unknown print mode %s, use one of body, headers, report", printMode)
 	}
+	save := !cCtx.Bool("no-save")
 
-	body, err := utils.ReadBody(pRes)
+	response, err := svc.RunRequest(reqPath, a, svc.RunOptions{
+		Version:  APP_VERSION,
+		Raw:      cCtx.Bool("raw"),
+		SortKeys: cCtx.Bool("sort-keys"),
+	})
 	if err != nil {
-		log.Fatal("[restler error]: Error reading response body, Send PR :D", err)
+		logger.Debug("error processing request:", err)
+		return err
 	}
 
-	responseBytes, err := prepareResponse(pReq, pRes, body)
-	if err != nil {
-		log.Fatal("[restler Error]: We can't process your response, Fix and send PR :D", err)
+	if save {
+		if err := svc.SaveResponse(response, formatters, cCtx.Bool("keep-raw")); err != nil {
+			return err
+		}
+	}
+
+	if printMode != "" {
+		if err := svc.PrintResponse(os.Stdout, response, printMode, formatters[0]); err != nil {
+			return fmt.Errorf("error printing response %w", err)
+		}
 	}
 
 	// TODO: update env file if only it exists
-	updateEnvPostScript(pReq, pRes, body)
+	updateEnvPostScript(response.Request, response)
 
-	outDir := filepath.Dir(reqPath)
-	baseName := filepath.Base(reqPath)
-	outName := strings.TrimSuffix(baseName, filepath.Ext(baseName))
-	newDir := filepath.Join(outDir, ".res."+outName)
-	if _, err := os.Stat(newDir); os.IsNotExist(err) {
-		os.Mkdir(newDir, 0755)
+	if len(response.Assertions) > 0 {
+		response.Assertions.WriteSummary(os.Stderr)
+		if err := response.Assertions.Err(); err != nil {
+			return &svc.Error{Kind: svc.ErrorKindAssertion, Path: reqPath, Err: err}
+		}
 	}
-	resName := fmt.Sprintf(".%s.%s.%s.res.md", outName, strings.ToLower(pReq.Method), strings.Replace(time.Now().Format("20060102150405.000000"), ".", "", 1))
-	resFullPath := filepath.Join(newDir, resName)
 
-	os.WriteFile(resFullPath, responseBytes, 0644)
+	if cCtx.Bool("status-exit") {
+		if code := svc.StatusExitCode(response.HTTP.StatusCode); code != 0 {
+			return cli.Exit("", code)
+		}
+	}
 	return nil
 }
 
-func updateEnvPostScript(req *svc.Request, res *http.Response, body []byte) {
-	if req.After == nil || req.After.Env == nil {
-		return
+// -------------------------
+// test command
+// -------------------------
+func testAction(cCtx *cli.Context) error {
+	if cCtx.IsSet("seed") {
+		svc.SetSeed(cCtx.Int64("seed"))
 	}
+	svc.SetAllowUndefined(cCtx.Bool("allow-undefined"))
+	svc.SetBareVars(cCtx.Bool("bare-vars"))
 
-	var envBodyMap = map[string]string{}
-	var envHeaderMap = map[string]string{}
+	formats := cCtx.StringSlice("format")
+	if len(formats) == 0 && a.Config != nil {
+		formats = a.Config.Formats
+	}
+	formatters, err := svc.GetFormatters(formats)
+	if err != nil {
+		return svc.NewError(svc.ErrorKindUsage, err)
+	}
+	reporter, err := svc.GetTestReporter(cCtx.String("reporter"))
+	if err != nil {
+		return svc.NewError(svc.ErrorKindUsage, err)
+	}
+	save := !cCtx.Bool("no-save")
 
-	for envKey, valKeys := range req.After.Env {
-		if valKeys != "" {
-			if strings.HasPrefix(valKeys, "Body") {
-				envBodyMap[envKey] = strings.TrimPrefix(valKeys, "Body")
-			}
-			if strings.HasPrefix(valKeys, "Header") {
-				envHeaderMap[envKey] = strings.TrimPrefix(valKeys, "Header")
-			}
-		}
+	paths := cCtx.Args().Slice()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := svc.FindRequestFiles(paths)
+	if err != nil {
+		return err
+	}
+	filter := svc.TestFilter{
+		Include:     cCtx.StringSlice("include"),
+		Exclude:     cCtx.StringSlice("exclude"),
+		Tags:        cCtx.StringSlice("tag"),
+		ExcludeTags: cCtx.StringSlice("exclude-tag"),
+	}
+	files, err = filter.Filter(files)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return svc.Errorf(svc.ErrorKindUsage, "no request files found in %s", strings.Join(paths, ", "))
 	}
 
-	var envBodyValueMap = map[string]string{}
-	var envHeaderValueMap = map[string]string{}
+	// requests running in parallel share the jar, so that they don't overwrite
+	// cookies of each other
+	if a.Config != nil && a.Config.CookieJar {
+		jar, err := svc.LoadCookieJar(svc.CookieJarPath(a.Config))
+		if err != nil {
+			return svc.Errorf(svc.ErrorKindFile, "error loading cookie jar %w", err)
+		}
+		a.Jar = jar
+	}
 
-	for envKey, envBodyKey := range envBodyMap {
-		var jsonBody map[string]interface{}
-		if envBodyKey != "" {
-			err := json.Unmarshal(body, &jsonBody)
-			if err != nil {
-				fmt.Println("[Update Env Log ] Body is not in JSON format: ", err)
-				return
-			}
-			if val, ok := utils.GetNestedValue(jsonBody, envBodyKey); ok {
-				envBodyValueMap[envKey] = fmt.Sprintf("%v", val)
-			} else {
-				fmt.Println("[Update Env Log] Value not found for key: ", envBodyKey)
-				envBodyValueMap[envKey] = ""
+	testEnv := svc.NewTestEnv(cCtx.Int("parallel"))
+	startedAt := time.Now()
+	results := svc.RunTests(files, cCtx.Int("parallel"), func(file string) svc.TestResult {
+		// every request records its timing in its own copy of the app
+		requestApp := *a
+		response, result := svc.TestRequest(file, &requestApp, svc.RunOptions{Version: APP_VERSION})
+		if response != nil {
+			if save {
+				if err := svc.SaveResponse(response, formatters, false); err != nil {
+					fmt.Fprintln(os.Stderr, "[restler Log]: Failed to write response file: ", err)
+				}
 			}
+			testEnv.Update(func() map[string]string {
+				return updateEnvPostScript(response.Request, response)
+			})
+		}
+		fmt.Fprintf(os.Stderr, "[restler Test]: %s %s\n", result.Result(), file)
+		return result
+	})
+	duration := time.Since(startedAt)
+
+	if output := cCtx.String("output"); output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			return svc.Errorf(svc.ErrorKindFile, "error creating report file %w", err)
 		}
+		defer file.Close()
+		if err := reporter.Report(file, results, duration); err != nil {
+			return fmt.Errorf("error writing report %w", err)
+		}
+		reporter, _ = svc.GetTestReporter(svc.DefaultTestReporter)
+	}
+	if err := reporter.Report(os.Stdout, results, duration); err != nil {
+		return fmt.Errorf("error writing report %w", err)
 	}
 
-	headerMap := utils.HeaderToMap(res.Header)
-	for envKey, envHeaderKey := range envHeaderMap {
-		if val, ok := utils.GetNestedValue(headerMap, envHeaderKey); ok {
-			envHeaderValueMap[envKey] = fmt.Sprintf("%v", val)
-		} else {
-			fmt.Println("[Update Env Log] Value not found for key: ", envHeaderKey)
-			envHeaderValueMap[envKey] = ""
+	// exit code is of the first failed request
+	for _, result := range results {
+		if !result.Passed() {
+			return cli.Exit("", result.ExitCode())
 		}
 	}
+	return nil
+}
 
-	// TODO: Verify if this works
-	newEnvMap := make(map[string]interface{}, len(envBodyValueMap)+len(envHeaderValueMap))
-	utils.MergeMaps(newEnvMap, utils.ConvertMap(envBodyValueMap))
-	utils.MergeMaps(newEnvMap, utils.ConvertMap(envHeaderValueMap))
-	err := env.UpdateEnvFile(a, newEnvMap)
+// -------------------------
+// validate command
+// -------------------------
+func validateAction(cCtx *cli.Context) error {
+	if cCtx.NArg() == 0 {
+		return svc.Errorf(svc.ErrorKindUsage, "Please provide request files or directories to validate")
+	}
+	svc.SetAllowUndefined(cCtx.Bool("allow-undefined"))
 
+	files, err := svc.FindRequestFiles(cCtx.Args().Slice())
 	if err != nil {
-		fmt.Println("[restler Log]: Failed to write env file: ", err)
+		return err
 	}
+
+	// every file is checked, exit code is of the first invalid file
+	var firstErr error
+	invalid := 0
+	for _, file := range files {
+		if _, err := svc.ParseRequest(file); err != nil {
+			svc.WriteError(os.Stderr, err, errorFormat)
+			invalid++
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	if invalid > 0 {
+		fmt.Fprintf(os.Stderr, "[restler Log]: %d of %d request files are invalid\n", invalid, len(files))
+		return cli.Exit("", svc.ExitCode(firstErr))
+	}
+	fmt.Fprintf(os.Stderr, "[restler Log]: %d request files are valid\n", len(files))
+	return nil
 }
 
-func getRequestBytes(req *svc.Request) ([]byte, error) {
-	if req.Body != nil {
-		return yaml.Marshal(req)
+// -------------------------
+// cookies command
+// -------------------------
+func cookiesListAction(cCtx *cli.Context) error {
+	jar, err := svc.LoadCookieJar(svc.CookieJarPath(a.Config))
+	if err != nil {
+		return svc.Errorf(svc.ErrorKindFile, "error loading cookies %w", err)
+	}
+	cookies := jar.All()
+	if len(cookies) == 0 {
+		fmt.Fprintln(os.Stderr, "[restler Log]: No cookies found")
+		return nil
 	}
-	return nil, nil
+	for _, c := range cookies {
+		expires := "session"
+		if c.Expires != nil {
+			expires = c.Expires.Format(time.RFC3339)
+		}
+		fmt.Printf("%s=%s; Domain=%s; Path=%s; Expires=%s; Secure=%t; HttpOnly=%t\n", c.Name, c.Value, c.Domain, c.Path, expires, c.Secure, c.HttpOnly)
+	}
+	return nil
 }
 
-func prepareResponse(req *svc.Request, res *http.Response, body []byte) ([]byte, error) {
-
-	var buffer bytes.Buffer
-	buffer.WriteString(fmt.Sprintf("# Response For: %s \n", req.Name))
-	buffer.WriteString(fmt.Sprintf("Status Code: %d, Status: %s\n", res.StatusCode, res.Status))
-	buffer.WriteString("\n\n")
-	buffer.WriteString("## Request Time\n")
-	buffer.WriteString(a.RequestTime.String())
-	buffer.WriteString("\n\n## Response Header: \n")
-
-	for key, value := range res.Header {
-		buffer.WriteString(fmt.Sprintf("%s: %s\n", key, value))
-	}
-	buffer.WriteString("\n\n")
-	buffer.WriteString("## Response Body: \n")
-	buffer.WriteString("```json\n")
-	buffer.Write(body)
-	buffer.WriteString("\n```")
-	buffer.WriteString("\n\n")
-	buffer.WriteString("## Original Request \n")
-	buffer.WriteString(fmt.Sprintf("Method: %s, URL: %s\n", res.Request.Method, res.Request.URL))
-	// ignoring errors here
-	requestBytes, _ := getRequestBytes(req)
-	buffer.WriteString("\n```yaml\n")
-	buffer.Write(requestBytes)
-	buffer.WriteString("\n```")
-	return buffer.Bytes(), nil
+func cookiesClearAction(cCtx *cli.Context) error {
+	jar, err := svc.LoadCookieJar(svc.CookieJarPath(a.Config))
+	if err != nil {
+		return svc.Errorf(svc.ErrorKindFile, "error loading cookies %w", err)
+	}
+	jar.Clear()
+	return jar.Save()
 }
 
-func validateRequest(r *svc.Request) error {
-	if r.Name == "" {
-		return errors.New("Request name is required")
+func cookiesDeleteAction(cCtx *cli.Context) error {
+	name := cCtx.Args().First()
+	if name == "" {
+		return svc.Errorf(svc.ErrorKindUsage, "Please provide name of the cookie")
 	}
-	if r.URL == "" {
-		return errors.New("Request URL is required")
+	jar, err := svc.LoadCookieJar(svc.CookieJarPath(a.Config))
+	if err != nil {
+		return svc.Errorf(svc.ErrorKindFile, "error loading cookies %w", err)
 	}
-	if r.Method == "" {
-		return errors.New("Request Method is required")
+	removed := jar.Delete(name, cCtx.String("domain"))
+	fmt.Fprintf(os.Stderr, "[restler Log]: Deleted %d cookies\n", removed)
+	return jar.Save()
+}
+
+func updateEnvPostScript(req *svc.Request, res *svc.Response) map[string]string {
+	if req.After == nil || req.After.Env == nil {
+		return nil
 	}
-	if r.Headers == nil {
-		return errors.New("Request Headers is required")
+
+	values, errs := svc.ExtractEnv(req.After.Env, res)
+	for envKey, err := range errs {
+		fmt.Fprintf(os.Stderr, "[Update Env Log] Value not found for %s: %s\n", envKey, err)
 	}
 
-	return nil
+	// TODO: Verify if this works
+	newEnvMap := utils.ConvertMap(values)
+	err := env.UpdateEnvFile(a, newEnvMap)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "[restler Log]: Failed to write env file: ", err)
+	}
+	return values
 }
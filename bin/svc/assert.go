@@ -0,0 +1,556 @@
+package svc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shrijan00003/restler/core/app"
+	"gopkg.in/yaml.v3"
+)
+
+// Assert describes the expected response, failed assertions are reported in
+// the response file and the command exits with ErrorKindAssertion eg.
+//
+//	Assert:
+//	  Status: [200, 3xx]
+//	  Headers:
+//	    Content-Type: application/json
+//	    X-Request-Id:
+//	      Exists: true
+//	  Body:
+//	    - Path: $.items[0].id
+//	      Eq: 1
+//	    - Path: items
+//	      Length: 2
+//	  ResponseTime: 500ms
+//	  Timing:
+//	    ttfb: 200ms
+type Assert struct {
+	Status  StatusAssert      `yaml:"Status,omitempty"`
+	Headers map[string]*Check `yaml:"Headers,omitempty"`
+	Body    []*BodyAssert     `yaml:"Body,omitempty"`
+	// ResponseTime is the upper bound of the total time of the request.
+	ResponseTime time.Duration `yaml:"ResponseTime,omitempty"`
+	// Timing has the upper bounds of the phases of the request.
+	Timing TimingAssert `yaml:"Timing,omitempty"`
+}
+
+// TimingAssert is the upper bound of the timing phases by their names eg. dns,
+// connect, tls, ttfb and transfer like the Timing extractor.
+type TimingAssert map[string]time.Duration
+
+func (t *TimingAssert) UnmarshalYAML(node *yaml.Node) error {
+	var timing map[string]time.Duration
+	if err := node.Decode(&timing); err != nil {
+		return err
+	}
+	for name := range timing {
+		if _, ok := (app.Timing{}).Values()[name]; !ok {
+			return fmt.Errorf("unknown Timing %s, use one of %s", name, strings.Join(timingNames(), ", "))
+		}
+	}
+	*t = timing
+	return nil
+}
+
+// StatusAssert is a status code, a class like 2xx, a range like 200-204 or a
+// list of them.
+type StatusAssert []StatusRange
+
+// StatusRange of the status codes, From and To are inclusive.
+type StatusRange struct {
+	From int
+	To   int
+	raw  string
+}
+
+func (s *StatusAssert) UnmarshalYAML(node *yaml.Node) error {
+	items := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		items = node.Content
+	}
+	*s = nil
+	for _, item := range items {
+		if item.Kind != yaml.ScalarNode {
+			return fmt.Errorf("status should be a code like 200, class like 2xx or range like 200-299")
+		}
+		statusRange, err := parseStatusRange(item.Value)
+		if err != nil {
+			return err
+		}
+		*s = append(*s, statusRange)
+	}
+	return nil
+}
+
+func (s StatusAssert) MarshalYAML() (interface{}, error) {
+	values := make([]string, len(s))
+	for i, statusRange := range s {
+		values[i] = statusRange.raw
+	}
+	return values, nil
+}
+
+func parseStatusRange(value string) (StatusRange, error) {
+	statusRange := StatusRange{raw: value}
+	invalid := fmt.Errorf("invalid status %q, use a code like 200, class like 2xx or range like 200-299", value)
+	if len(value) == 3 && strings.HasSuffix(strings.ToLower(value), "xx") && value[0] >= '1' && value[0] <= '5' {
+		statusRange.From = int(value[0]-'0') * 100
+		statusRange.To = statusRange.From + 99
+		return statusRange, nil
+	}
+	from, to, isRange := strings.Cut(value, "-")
+	var err error
+	if statusRange.From, err = strconv.Atoi(strings.TrimSpace(from)); err != nil {
+		return statusRange, invalid
+	}
+	statusRange.To = statusRange.From
+	if isRange {
+		if statusRange.To, err = strconv.Atoi(strings.TrimSpace(to)); err != nil {
+			return statusRange, invalid
+		}
+	}
+	if statusRange.From < 100 || statusRange.To > 599 || statusRange.From > statusRange.To {
+		return statusRange, invalid
+	}
+	return statusRange, nil
+}
+
+// Match returns true when the code is in any of the ranges.
+func (s StatusAssert) Match(code int) bool {
+	for _, statusRange := range s {
+		if code >= statusRange.From && code <= statusRange.To {
+			return true
+		}
+	}
+	return false
+}
+
+func (s StatusAssert) String() string {
+	values := make([]string, len(s))
+	for i, statusRange := range s {
+		values[i] = statusRange.raw
+	}
+	return strings.Join(values, ", ")
+}
+
+// BodyAssert checks the value at Path of the JSON body, Path is JSONPath when
+// it starts with $, bracket path like [a][0] or JMESPath otherwise and empty
+// Path is the whole body as text.
+type BodyAssert struct {
+	Path  string `yaml:"Path"`
+	Check `yaml:",inline"`
+}
+
+// Check of a header or a body value, a scalar in the request file is
+// the shorthand of Eq eg. Content-Type: application/json
+type Check struct {
+	Exists   *bool       `yaml:"Exists,omitempty"`
+	Eq       interface{} `yaml:"Eq,omitempty"`
+	Ne       interface{} `yaml:"Ne,omitempty"`
+	Gt       *float64    `yaml:"Gt,omitempty"`
+	Gte      *float64    `yaml:"Gte,omitempty"`
+	Lt       *float64    `yaml:"Lt,omitempty"`
+	Lte      *float64    `yaml:"Lte,omitempty"`
+	Contains interface{} `yaml:"Contains,omitempty"`
+	Matches  string      `yaml:"Matches,omitempty"`
+	// Type is one of string, number, integer, boolean, object, array or null
+	Type   string `yaml:"Type,omitempty"`
+	Length *int   `yaml:"Length,omitempty"`
+
+	// null Eq and Ne are kept apart from missing ones
+	eqSet bool
+	neSet bool
+}
+
+// plainCheck is decoded without UnmarshalYAML of Check
+type plainCheck Check
+
+var checkTypes = []string{"string", "number", "integer", "boolean", "object", "array", "null"}
+
+func (c *Check) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		c.eqSet = true
+		return node.Decode(&c.Eq)
+	}
+	if err := node.Decode((*plainCheck)(c)); err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		switch node.Content[i].Value {
+		case "Eq":
+			c.eqSet = true
+		case "Ne":
+			c.neSet = true
+		}
+	}
+	return c.validate()
+}
+
+func (c *Check) validate() error {
+	if c.Matches != "" {
+		if _, err := regexp.Compile(c.Matches); err != nil {
+			return fmt.Errorf("invalid Matches %q: %w", c.Matches, err)
+		}
+	}
+	if c.Type != "" && !contains(checkTypes, c.Type) {
+		return fmt.Errorf("unknown Type %s, use one of %s", c.Type, strings.Join(checkTypes, ", "))
+	}
+	return nil
+}
+
+func (b *BodyAssert) UnmarshalYAML(node *yaml.Node) error {
+	var path struct {
+		Path string `yaml:"Path"`
+	}
+	if err := node.Decode(&path); err != nil {
+		return err
+	}
+	b.Path = path.Path
+	// fields other than Path are the check
+	check := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != "Path" {
+			check.Content = append(check.Content, node.Content[i], node.Content[i+1])
+		}
+	}
+	return b.Check.UnmarshalYAML(check)
+}
+
+func (b BodyAssert) MarshalYAML() (interface{}, error) {
+	return struct {
+		Path       string `yaml:"Path,omitempty"`
+		plainCheck `yaml:",inline"`
+	}{b.Path, plainCheck(b.Check)}, nil
+}
+
+// AssertResult is the result of a single assertion.
+type AssertResult struct {
+	Name    string `json:"name" yaml:"name"`
+	Passed  bool   `json:"passed" yaml:"passed"`
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// Result is PASS or FAIL.
+func (r AssertResult) Result() string {
+	if r.Passed {
+		return "PASS"
+	}
+	return "FAIL"
+}
+
+// AssertResults of the response in the order of the Assert section.
+type AssertResults []AssertResult
+
+// Failed returns number of the failed assertions.
+func (r AssertResults) Failed() int {
+	failed := 0
+	for _, result := range r {
+		if !result.Passed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// WriteSummary writes result of every assertion and the number of failures.
+func (r AssertResults) WriteSummary(w io.Writer) {
+	for _, result := range r {
+		if result.Message != "" {
+			fmt.Fprintf(w, "[restler Assert]: %s %s: %s\n", result.Result(), result.Name, result.Message)
+		} else {
+			fmt.Fprintf(w, "[restler Assert]: %s %s\n", result.Result(), result.Name)
+		}
+	}
+	fmt.Fprintf(w, "[restler Assert]: %d passed, %d failed\n", len(r)-r.Failed(), r.Failed())
+}
+
+// Err returns ErrorKindAssertion error when any assertion failed.
+func (r AssertResults) Err() error {
+	if failed := r.Failed(); failed > 0 {
+		return Errorf(ErrorKindAssertion, "%d of %d assertions failed", failed, len(r))
+	}
+	return nil
+}
+
+// Evaluate checks the response against the assertions.
+func (a *Assert) Evaluate(res *Response) AssertResults {
+	var results AssertResults
+	if a == nil {
+		return results
+	}
+
+	if len(a.Status) > 0 {
+		result := AssertResult{Name: fmt.Sprintf("Status is %s", a.Status), Passed: a.Status.Match(res.HTTP.StatusCode)}
+		if !result.Passed {
+			result.Message = fmt.Sprintf("got %d", res.HTTP.StatusCode)
+		}
+		results = append(results, result)
+	}
+
+	names := make([]string, 0, len(a.Headers))
+	for name := range a.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		check := a.Headers[name]
+		if check == nil {
+			continue
+		}
+		var value interface{}
+		values := res.HTTP.Header.Values(name)
+		if len(values) > 0 {
+			value = strings.Join(values, ", ")
+		}
+		results = append(results, check.evaluate("Header "+name, value, len(values) > 0, true)...)
+	}
+
+	body := &jsonBody{raw: res.Body}
+	for _, bodyAssert := range a.Body {
+		if bodyAssert == nil {
+			continue
+		}
+		name := "Body"
+		if bodyAssert.Path != "" {
+			name = "Body " + bodyAssert.Path
+		}
+		value, err := bodyAssert.value(res, body)
+		if err != nil && err != ErrValueNotFound {
+			results = append(results, AssertResult{Name: name, Message: err.Error()})
+			continue
+		}
+		results = append(results, bodyAssert.evaluate(name, value, err == nil, false)...)
+	}
+
+	if a.ResponseTime > 0 {
+		result := AssertResult{
+			Name:   fmt.Sprintf("ResponseTime <= %s", a.ResponseTime),
+			Passed: res.Timing.Total <= a.ResponseTime,
+		}
+		if !result.Passed {
+			result.Message = fmt.Sprintf("got %s", res.Timing.Total.Round(time.Microsecond))
+		}
+		results = append(results, result)
+	}
+
+	if len(a.Timing) > 0 {
+		values := res.Timing.Values()
+		for _, name := range timingNames() {
+			limit, ok := a.Timing[name]
+			if !ok || limit <= 0 {
+				continue
+			}
+			result := AssertResult{
+				Name:   fmt.Sprintf("Timing %s <= %s", name, limit),
+				Passed: values[name] <= limit,
+			}
+			if !result.Passed {
+				result.Message = fmt.Sprintf("got %s", values[name].Round(time.Microsecond))
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// EvaluateResponse checks the response against Assert and Schema of the
+// request, schema is reported as one of the assertions and its violations are
+// set to the response.
+func EvaluateResponse(res *Response) error {
+	req := res.Request
+	res.Assertions = req.Assert.Evaluate(res)
+	if req.Schema == nil {
+		return nil
+	}
+	violations, err := req.Schema.Validate(req.Dir, res.Body)
+	if err != nil {
+		return err
+	}
+	res.SchemaViolations = violations
+	result := AssertResult{Name: fmt.Sprintf("Body matches schema %s", req.Schema), Passed: len(violations) == 0}
+	if !result.Passed {
+		result.Message = fmt.Sprintf("%d violations, first at %s: %s", len(violations), violations[0].DisplayPointer(), violations[0].Message)
+	}
+	res.Assertions = append(res.Assertions, result)
+	return nil
+}
+
+func (b *BodyAssert) value(res *Response, body *jsonBody) (interface{}, error) {
+	if b.Path == "" {
+		return string(res.Body), nil
+	}
+	data, err := body.get()
+	if err != nil {
+		return nil, err
+	}
+	value, err := pathValue(b.Path, data)
+	if errors.Is(err, ErrValueNotFound) {
+		return nil, ErrValueNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Path, err)
+	}
+	// missing values and null are the same for JMESPath
+	if value == nil {
+		return nil, ErrValueNotFound
+	}
+	return value, nil
+}
+
+// evaluate runs every check which is set, header values are strings so that
+// expected values are compared as strings.
+func (c *Check) evaluate(name string, value interface{}, found bool, text bool) AssertResults {
+	var results AssertResults
+	add := func(op string, expected interface{}, passed bool) {
+		result := AssertResult{Name: fmt.Sprintf("%s %s %s", name, op, formatValue(expected)), Passed: passed}
+		if !passed {
+			if found {
+				result.Message = "got " + formatValue(value)
+			} else {
+				result.Message = "not found"
+			}
+		}
+		results = append(results, result)
+	}
+
+	if c.Exists != nil {
+		add("exists", *c.Exists, found == *c.Exists)
+	}
+	if c.eqSet {
+		add("eq", c.Eq, (found || c.Eq == nil) && equal(value, c.Eq, text))
+	}
+	if c.neSet {
+		add("ne", c.Ne, !equal(value, c.Ne, text))
+	}
+	number, isNumber := toNumber(value)
+	if c.Gt != nil {
+		add("gt", *c.Gt, isNumber && number > *c.Gt)
+	}
+	if c.Gte != nil {
+		add("gte", *c.Gte, isNumber && number >= *c.Gte)
+	}
+	if c.Lt != nil {
+		add("lt", *c.Lt, isNumber && number < *c.Lt)
+	}
+	if c.Lte != nil {
+		add("lte", *c.Lte, isNumber && number <= *c.Lte)
+	}
+	if c.Contains != nil {
+		add("contains", c.Contains, found && containsValue(value, c.Contains))
+	}
+	if c.Matches != "" {
+		add("matches", c.Matches, found && regexp.MustCompile(c.Matches).MatchString(formatValue(value)))
+	}
+	if c.Type != "" {
+		add("type", c.Type, (found || c.Type == "null") && typeOf(value) == c.Type ||
+			c.Type == "number" && typeOf(value) == "integer")
+	}
+	if c.Length != nil {
+		length, ok := lengthOf(value)
+		add("length", *c.Length, found && ok && length == *c.Length)
+	}
+	return results
+}
+
+// normalize converts yaml values to the types of encoding/json, so that they
+// can be compared with the values of the body
+func normalize(value interface{}) interface{} {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(encoded, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
+
+func equal(value interface{}, expected interface{}, text bool) bool {
+	if text && expected != nil {
+		return value != nil && formatValue(value) == formatValue(expected)
+	}
+	return reflect.DeepEqual(value, normalize(expected))
+}
+
+// containsValue checks substring of strings, item of lists and key of maps
+func containsValue(value interface{}, expected interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return strings.Contains(v, formatValue(expected))
+	case []interface{}:
+		expected = normalize(expected)
+		for _, item := range v {
+			if reflect.DeepEqual(item, expected) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		_, ok := v[formatValue(expected)]
+		return ok
+	}
+	return false
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func lengthOf(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case string:
+		return utf8.RuneCountInString(v), true
+	case []interface{}:
+		return len(v), true
+	case map[string]interface{}:
+		return len(v), true
+	}
+	return 0, false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
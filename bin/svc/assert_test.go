@@ -0,0 +1,170 @@
+package svc
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shrijan00003/restler/core/app"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseStatusRange(t *testing.T) {
+	tests := []struct {
+		value    string
+		from, to int
+		wantErr  bool
+	}{
+		{value: "200", from: 200, to: 200},
+		{value: "2xx", from: 200, to: 299},
+		{value: "5XX", from: 500, to: 599},
+		{value: "200-204", from: 200, to: 204},
+		{value: "200 - 204", from: 200, to: 204},
+		{value: "6xx", wantErr: true},
+		{value: "0xx", wantErr: true},
+		{value: "2x", wantErr: true},
+		{value: "99", wantErr: true},
+		{value: "600", wantErr: true},
+		{value: "204-200", wantErr: true},
+		{value: "200-", wantErr: true},
+		{value: "ok", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseStatusRange(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseStatusRange(%q) = %+v, want error", tt.value, got)
+			}
+			continue
+		}
+		if err != nil || got.From != tt.from || got.To != tt.to {
+			t.Errorf("parseStatusRange(%q) = %+v, %v, want %d-%d", tt.value, got, err, tt.from, tt.to)
+		}
+	}
+}
+
+func TestStatusAssert(t *testing.T) {
+	tests := []struct {
+		input    string
+		match    []int
+		notMatch []int
+	}{
+		{"201", []int{201}, []int{200, 202}},
+		{"3xx", []int{300, 302, 399}, []int{299, 400}},
+		{"[200, 3xx, 404-405]", []int{200, 301, 404, 405}, []int{201, 406}},
+	}
+	for _, tt := range tests {
+		var status StatusAssert
+		if err := yaml.Unmarshal([]byte(tt.input), &status); err != nil {
+			t.Errorf("unmarshal %q: %v", tt.input, err)
+			continue
+		}
+		for _, code := range tt.match {
+			if !status.Match(code) {
+				t.Errorf("%s should match %d", tt.input, code)
+			}
+		}
+		for _, code := range tt.notMatch {
+			if status.Match(code) {
+				t.Errorf("%s should not match %d", tt.input, code)
+			}
+		}
+	}
+
+	var status StatusAssert
+	if err := yaml.Unmarshal([]byte("{code: 200}"), &status); err == nil {
+		t.Errorf("map status should be an error")
+	}
+}
+
+func TestAssertEvaluate(t *testing.T) {
+	input := `
+Status: 2xx
+Headers:
+  Content-Type: application/json
+  X-Request-Id:
+    Exists: true
+Body:
+  - Path: $.items[0].id
+    Eq: 1
+    Type: integer
+  - Path: length(items)
+    Gte: 2
+  - Path: items[].id
+    Contains: 3
+  - Path: $.name
+    Matches: ^rest
+  - Path: $.missing
+    Exists: false
+ResponseTime: 1s
+Timing:
+  dns: 10ms
+  connect: 50ms
+  ttfb: 100ms
+`
+	var assert Assert
+	if err := yaml.Unmarshal([]byte(input), &assert); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	res := &Response{
+		HTTP: &http.Response{StatusCode: 200, Header: header},
+		Body: []byte(`{"name": "restler", "items": [{"id": 1}, {"id": 2}]}`),
+		Timing: app.Timing{
+			DNSLookup:       5 * time.Millisecond,
+			TCPConnect:      50 * time.Millisecond,
+			TimeToFirstByte: 1500 * time.Millisecond,
+			Total:           2 * time.Second,
+		},
+	}
+
+	var failed []string
+	results := assert.Evaluate(res)
+	for _, result := range results {
+		if !result.Passed {
+			failed = append(failed, result.Name+": "+result.Message)
+		}
+	}
+	want := []string{
+		"Header X-Request-Id exists true: not found",
+		"Body items[].id contains 3: got [1,2]",
+		"ResponseTime <= 1s: got 2s",
+		"Timing ttfb <= 100ms: got 1.5s",
+	}
+	if strings.Join(failed, "\n") != strings.Join(want, "\n") {
+		t.Errorf("failed assertions\n%s\nwant\n%s", strings.Join(failed, "\n"), strings.Join(want, "\n"))
+	}
+	if err := results.Err(); err == nil || ErrorKindOf(err) != ErrorKindAssertion {
+		t.Errorf("Err() = %v, want assertion error", err)
+	}
+}
+
+func TestTimingAssert(t *testing.T) {
+	var assert Assert
+	if err := yaml.Unmarshal([]byte("Timing: {tls: 20ms, transfer: 1s}"), &assert); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	want := TimingAssert{"tls": 20 * time.Millisecond, "transfer": time.Second}
+	if !reflect.DeepEqual(assert.Timing, want) {
+		t.Errorf("Timing = %v, want %v", assert.Timing, want)
+	}
+
+	res := &Response{Timing: app.Timing{TLSHandshake: 30 * time.Millisecond, ContentTransfer: time.Second}}
+	var names []string
+	for _, result := range assert.Evaluate(res) {
+		names = append(names, fmt.Sprintf("%s %s", result.Result(), result.Name))
+	}
+	if got := strings.Join(names, ", "); got != "FAIL Timing tls <= 20ms, PASS Timing transfer <= 1s" {
+		t.Errorf("results = %s", got)
+	}
+
+	err := yaml.Unmarshal([]byte("Timing: {latency: 1s}"), &assert)
+	if err == nil || !strings.Contains(err.Error(), "unknown Timing latency, use one of connect, dns, tls, total, transfer, ttfb") {
+		t.Errorf("unknown timing error = %v", err)
+	}
+}
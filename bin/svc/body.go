@@ -0,0 +1,334 @@
+package svc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Part is a summary of a single multipart/form-data part, it is used for the
+// response file so that we don't have to dump the raw (possibly binary) content.
+type Part struct {
+	Name        string `json:"name" yaml:"name"`
+	Filename    string `json:"filename,omitempty" yaml:"filename,omitempty"`
+	ContentType string `json:"contentType,omitempty" yaml:"contentType,omitempty"`
+	Size        int64  `json:"size" yaml:"size"`
+}
+
+// Body modes supported by the request file, when BodyMode is not set in the
+// request it is detected from the Content-Type header and the Body itself.
+const (
+	BodyModeJSON      = "json"
+	BodyModeForm      = "form"
+	BodyModeMultipart = "multipart"
+	BodyModeRaw       = "raw"
+	BodyModeFile      = "file"
+	BodyModeBinary    = "binary"
+)
+
+// buildBody will return the request body reader along with the content type
+// that has to be sent with the request, empty content type means headers from
+// the request file are used as is.
+func buildBody(req *Request) (io.Reader, string, error) {
+	switch bodyMode(req) {
+	case BodyModeForm:
+		return buildFormBody(req)
+	case BodyModeMultipart:
+		return buildMultipartBody(req)
+	case BodyModeRaw:
+		return buildRawBody(req)
+	case BodyModeFile, BodyModeBinary:
+		return buildFileBody(req)
+	case BodyModeJSON:
+		return buildJSONBody(req)
+	default:
+		return nil, "", fmt.Errorf("unsupported BodyMode %s, use one of json, form, multipart, raw, file, binary", req.BodyMode)
+	}
+}
+
+func bodyMode(req *Request) string {
+	if req.BodyMode != "" {
+		return strings.ToLower(req.BodyMode)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(req.Headers["Content-Type"])
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		return BodyModeForm
+	case mediaType == "multipart/form-data":
+		return BodyModeMultipart
+	}
+
+	// string body with non json content type is sent as it is eg. xml, text
+	if _, ok := req.Body.(string); ok && mediaType != "" && !isJSONMediaType(mediaType) {
+		return BodyModeRaw
+	}
+	return BodyModeJSON
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// +++++++++++++++++++++++++++++++++++++++++++++
+// json request body
+// +++++++++++++++++++++++++++++++++++++++++++++
+func buildJSONBody(req *Request) (io.Reader, string, error) {
+	var parsedBodyBytes []byte
+	var err error
+	if req.Body != nil {
+		parsedBodyBytes, err = json.Marshal(req.Body)
+		if err != nil {
+			return nil, "", fmt.Errorf("error parsing request body %s", err)
+		}
+	}
+	return bytes.NewReader(parsedBodyBytes), "", nil
+}
+
+// +++++++++++++++++++++++++++++++++++++++++++++
+// raw string body eg. xml, text, ndjson
+// +++++++++++++++++++++++++++++++++++++++++++++
+func buildRawBody(req *Request) (io.Reader, string, error) {
+	if req.Body == nil {
+		return strings.NewReader(""), defaultContentType(req, "text/plain; charset=utf-8"), nil
+	}
+	raw, ok := req.Body.(string)
+	if !ok {
+		return nil, "", fmt.Errorf("raw body should be a string, use block scalar (|) for multiline body")
+	}
+	return strings.NewReader(raw), defaultContentType(req, "text/plain; charset=utf-8"), nil
+}
+
+// +++++++++++++++++++++++++++++++++++++++++++++
+// body loaded from a file next to the request
+// +++++++++++++++++++++++++++++++++++++++++++++
+func buildFileBody(req *Request) (io.Reader, string, error) {
+	file, ok := req.Body.(string)
+	if !ok || file == "" {
+		return nil, "", fmt.Errorf("%s body should be a path to the file", req.BodyMode)
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(req.Dir, file)
+	}
+
+	fileBytes, err := os.ReadFile(file)
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading body file %s", err)
+	}
+
+	fallback := "application/octet-stream"
+	if bodyMode(req) == BodyModeFile {
+		if byExt := mime.TypeByExtension(filepath.Ext(file)); byExt != "" {
+			fallback = byExt
+		}
+	}
+	return bytes.NewReader(fileBytes), defaultContentType(req, fallback), nil
+}
+
+// defaultContentType returns fallback only if request doesn't have its own
+// Content-Type header, content type from the request is always honored.
+func defaultContentType(req *Request, fallback string) string {
+	if req.Headers["Content-Type"] != "" {
+		return ""
+	}
+	return fallback
+}
+
+// +++++++++++++++++++++++++++++++++++++++++++++
+// application/x-www-form-urlencoded body
+// +++++++++++++++++++++++++++++++++++++++++++++
+// Nested maps are encoded with bracket notation (a[b][c]=v) and lists are
+// encoded as repeated keys (a=1&a=2).
+func buildFormBody(req *Request) (io.Reader, string, error) {
+	rawFormData := url.Values{}
+	if req.Body != nil {
+		fields, ok := req.Body.(map[string]interface{})
+		if !ok {
+			return nil, "", fmt.Errorf("[application/x-www-form-urlencoded] body should be a map of fields")
+		}
+		for key, val := range fields {
+			if err := encodeFormValue(rawFormData, key, val); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	return strings.NewReader(rawFormData.Encode()), "", nil
+}
+
+func encodeFormValue(values url.Values, key string, val interface{}) error {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for subKey, subVal := range v {
+			if err := encodeFormValue(values, fmt.Sprintf("%s[%s]", key, subKey), subVal); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			scalar, ok := formScalar(item)
+			if !ok {
+				return fmt.Errorf("[application/x-www-form-urlencoded] unsupported value for field %s, list should only have scalar values", key)
+			}
+			values.Add(key, scalar)
+		}
+	default:
+		scalar, ok := formScalar(v)
+		if !ok {
+			return fmt.Errorf("[application/x-www-form-urlencoded] unsupported value of type %T for field %s", v, key)
+		}
+		values.Add(key, scalar)
+	}
+	return nil
+}
+
+func formScalar(val interface{}) (string, bool) {
+	switch v := val.(type) {
+	case nil:
+		return "", true
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// +++++++++++++++++++++++++++++++++++++++++++++
+// multipart/form-data body
+// +++++++++++++++++++++++++++++++++++++++++++++
+// Body is expected to be a map where each key is the field name, and value is
+// either a text value, a list of values for repeated fields or a map describing
+// the part eg.
+//
+//	Body:
+//	  name: John
+//	  avatar:
+//	    File: ./avatar.png        # relative to the request file
+//	    Filename: me.png          # optional, defaults to file name
+//	    Content-Type: image/png   # optional, detected from extension
+//	  meta:
+//	    Value: '{"public": true}'
+//	    Content-Type: application/json
+func buildMultipartBody(req *Request) (io.Reader, string, error) {
+	fields, ok := req.Body.(map[string]interface{})
+	if req.Body != nil && !ok {
+		return nil, "", fmt.Errorf("multipart/form-data body should be a map of fields")
+	}
+
+	var buffer bytes.Buffer
+	writer := multipart.NewWriter(&buffer)
+	req.Parts = nil
+
+	// map iteration is random, sort keys so that parts are always in same order
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		values, ok := fields[key].([]interface{})
+		if !ok {
+			values = []interface{}{fields[key]}
+		}
+		for _, val := range values {
+			part, err := writeMultipartPart(writer, req.Dir, key, val)
+			if err != nil {
+				return nil, "", fmt.Errorf("error writing multipart field %s: %w", key, err)
+			}
+			req.Parts = append(req.Parts, part)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("error closing multipart body %s", err)
+	}
+
+	return &buffer, writer.FormDataContentType(), nil
+}
+
+func writeMultipartPart(writer *multipart.Writer, dir string, name string, val interface{}) (Part, error) {
+	part := Part{Name: name}
+	var content []byte
+
+	switch v := val.(type) {
+	case map[string]interface{}:
+		file, _ := v["File"].(string)
+		value := v["Value"]
+		part.Filename, _ = v["Filename"].(string)
+		part.ContentType, _ = v["Content-Type"].(string)
+
+		if file != "" {
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(dir, file)
+			}
+			fileBytes, err := os.ReadFile(file)
+			if err != nil {
+				return part, err
+			}
+			content = fileBytes
+			if part.Filename == "" {
+				part.Filename = filepath.Base(file)
+			}
+			if part.ContentType == "" {
+				part.ContentType = mime.TypeByExtension(filepath.Ext(file))
+			}
+			if part.ContentType == "" {
+				part.ContentType = "application/octet-stream"
+			}
+		} else if value != nil {
+			content = []byte(fmt.Sprintf("%v", value))
+		}
+	case []interface{}:
+		return part, fmt.Errorf("nested list is not supported")
+	case nil:
+	default:
+		content = []byte(fmt.Sprintf("%v", v))
+	}
+
+	header := make(textproto.MIMEHeader)
+	disposition := fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(name))
+	if part.Filename != "" {
+		disposition += fmt.Sprintf(`; filename="%s"`, escapeQuotes(part.Filename))
+	}
+	header.Set("Content-Disposition", disposition)
+	if part.ContentType != "" {
+		header.Set("Content-Type", part.ContentType)
+	}
+
+	w, err := writer.CreatePart(header)
+	if err != nil {
+		return part, err
+	}
+	n, err := w.Write(content)
+	if err != nil {
+		return part, err
+	}
+	part.Size = int64(n)
+	return part, nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
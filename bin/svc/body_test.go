@@ -0,0 +1,267 @@
+package svc
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testPart struct {
+	name, filename, contentType, content string
+}
+
+func readMultipart(t *testing.T, body io.Reader, contentType string) []testPart {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		t.Fatalf("content type = %q, want multipart/form-data with boundary", contentType)
+	}
+	reader := multipart.NewReader(body, params["boundary"])
+	var parts []testPart
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return parts
+		}
+		if err != nil {
+			t.Fatalf("reading multipart body: %v", err)
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, testPart{part.FormName(), part.FileName(), part.Header.Get("Content-Type"), string(content)})
+	}
+}
+
+func TestMultipartBody(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "avatar.png"), []byte("avatar content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	req := &Request{
+		Dir:     dir,
+		Headers: map[string]string{"Content-Type": "multipart/form-data"},
+		Body: map[string]interface{}{
+			"name": "John",
+			"tags": []interface{}{"a", 2},
+			"avatar": map[string]interface{}{
+				"File": "avatar.png",
+			},
+			"photo": map[string]interface{}{
+				"File":         "avatar.png",
+				"Filename":     "me.png",
+				"Content-Type": "image/jpeg",
+			},
+			"meta": map[string]interface{}{
+				"Value":        `{"public": true}`,
+				"Content-Type": "application/json",
+			},
+		},
+	}
+	body, contentType, err := buildBody(req)
+	if err != nil {
+		t.Fatalf("buildBody error: %v", err)
+	}
+
+	// parts are sorted by name and repeated fields keep their order
+	want := []testPart{
+		{"avatar", "avatar.png", "image/png", "avatar content"},
+		{"meta", "", "application/json", `{"public": true}`},
+		{"name", "", "", "John"},
+		{"photo", "me.png", "image/jpeg", "avatar content"},
+		{"tags", "", "", "a"},
+		{"tags", "", "", "2"},
+	}
+	got := readMultipart(t, body, contentType)
+	if len(got) != len(want) {
+		t.Fatalf("parts = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("part %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if len(req.Parts) != len(want) || req.Parts[3].Filename != "me.png" || req.Parts[3].Size != int64(len("avatar content")) {
+		t.Errorf("request parts = %+v, want summary of every part", req.Parts)
+	}
+}
+
+func TestMultipartBoundary(t *testing.T) {
+	req := &Request{
+		Headers: map[string]string{"Content-Type": "multipart/form-data"},
+		Body:    map[string]interface{}{"name": "John"},
+	}
+	_, first, err := buildBody(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, second, err := buildBody(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Errorf("boundary should be unique for every body, got %q twice", first)
+	}
+}
+
+func TestMultipartBodyErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    interface{}
+		wantErr string
+	}{
+		{"not a map", []interface{}{"a"}, "should be a map of fields"},
+		{"nested list", map[string]interface{}{"ids": []interface{}{[]interface{}{1}}}, "nested list is not supported"},
+		{"missing file", map[string]interface{}{"avatar": map[string]interface{}{"File": "missing.png"}}, "error writing multipart field avatar"},
+	}
+	for _, tt := range tests {
+		req := &Request{
+			Dir:     t.TempDir(),
+			Headers: map[string]string{"Content-Type": "multipart/form-data"},
+			Body:    tt.body,
+		}
+		_, _, err := buildBody(req)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestBodyMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        string
+		contentType string
+		body        interface{}
+		want        string
+	}{
+		{"default", "", "", map[string]interface{}{"a": 1}, BodyModeJSON},
+		{"explicit", "Raw", "", "text", BodyModeRaw},
+		{"form", "", "application/x-www-form-urlencoded", nil, BodyModeForm},
+		{"multipart", "", "multipart/form-data; boundary=x", nil, BodyModeMultipart},
+		{"xml string", "", "application/xml", "<a/>", BodyModeRaw},
+		{"json string", "", "application/json", "text", BodyModeJSON},
+		{"json suffix string", "", "application/problem+json", "text", BodyModeJSON},
+		{"string without content type", "", "", "text", BodyModeJSON},
+	}
+	for _, tt := range tests {
+		req := &Request{BodyMode: tt.mode, Body: tt.body, Headers: map[string]string{}}
+		if tt.contentType != "" {
+			req.Headers["Content-Type"] = tt.contentType
+		}
+		if got := bodyMode(req); got != tt.want {
+			t.Errorf("%s: bodyMode = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBodyModes(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "post.json"), []byte(`{"id": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		req             *Request
+		wantBody        string
+		wantContentType string
+	}{
+		{"json", &Request{Body: map[string]interface{}{"id": 1}}, `{"id":1}`, ""},
+		{"raw", &Request{BodyMode: BodyModeRaw, Body: "line 1\nline 2"}, "line 1\nline 2", "text/plain; charset=utf-8"},
+		{"raw with content type", &Request{Body: "<a/>", Headers: map[string]string{"Content-Type": "application/xml"}}, "<a/>", ""},
+		{"file", &Request{BodyMode: BodyModeFile, Body: "post.json"}, `{"id": 1}`, "application/json"},
+		{"binary", &Request{BodyMode: BodyModeBinary, Body: "post.json"}, `{"id": 1}`, "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		tt.req.Dir = dir
+		if tt.req.Headers == nil {
+			tt.req.Headers = map[string]string{}
+		}
+		body, contentType, err := buildBody(tt.req)
+		if err != nil {
+			t.Errorf("%s: buildBody error: %v", tt.name, err)
+			continue
+		}
+		content, _ := io.ReadAll(body)
+		if string(content) != tt.wantBody || contentType != tt.wantContentType {
+			t.Errorf("%s: body = %q, content type = %q, want %q, %q", tt.name, content, contentType, tt.wantBody, tt.wantContentType)
+		}
+	}
+}
+
+func TestBodyModeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *Request
+		wantErr string
+	}{
+		{"unknown mode", &Request{BodyMode: "xml"}, "unsupported BodyMode xml"},
+		{"raw map", &Request{BodyMode: BodyModeRaw, Body: map[string]interface{}{}}, "raw body should be a string"},
+		{"file without path", &Request{BodyMode: BodyModeFile}, "body should be a path to the file"},
+		{"missing file", &Request{BodyMode: BodyModeBinary, Body: "missing.bin"}, "error reading body file"},
+	}
+	for _, tt := range tests {
+		tt.req.Dir = t.TempDir()
+		_, _, err := buildBody(tt.req)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestFormBody(t *testing.T) {
+	req := &Request{
+		Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		Body: map[string]interface{}{
+			"name":   "John Doe",
+			"age":    30,
+			"admin":  false,
+			"score":  9.5,
+			"empty":  nil,
+			"tags":   []interface{}{"a", "b"},
+			"filter": map[string]interface{}{"status": "active", "range": map[string]interface{}{"from": 1}},
+		},
+	}
+	body, contentType, err := buildBody(req)
+	if err != nil {
+		t.Fatalf("buildBody error: %v", err)
+	}
+	content, _ := io.ReadAll(body)
+	want := "admin=false&age=30&empty=&filter%5Brange%5D%5Bfrom%5D=1&filter%5Bstatus%5D=active&name=John+Doe&score=9.5&tags=a&tags=b"
+	if string(content) != want {
+		t.Errorf("body = %s, want %s", content, want)
+	}
+	if contentType != "" {
+		t.Errorf("content type = %q, want the header of the request", contentType)
+	}
+}
+
+func TestFormBodyErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    interface{}
+		wantErr string
+	}{
+		{"not a map", "a=1", "body should be a map of fields"},
+		{"nested list", map[string]interface{}{"ids": []interface{}{[]interface{}{1}}}, "unsupported value for field ids"},
+		{"map in list", map[string]interface{}{"ids": []interface{}{map[string]interface{}{"a": 1}}}, "unsupported value for field ids"},
+	}
+	for _, tt := range tests {
+		req := &Request{
+			Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+			Body:    tt.body,
+		}
+		_, _, err := buildBody(req)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package svc
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/shrijan00003/restler/core/app"
+)
+
+// newClient creates http client for the request with proxy and timeouts.
+func newClient(req *Request, a *app.App) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// response is decoded by restler, so that compressed size can be reported
+	transport.DisableCompression = true
+	client := &http.Client{
+		Transport:     transport,
+		CheckRedirect: checkRedirect(req.Redirect),
+	}
+
+	proxy := req.Proxy
+	if proxy == nil {
+		proxy = &Proxy{}
+	}
+
+	if proxy.Enable != nil && !*proxy.Enable {
+		transport.Proxy = nil
+	} else if proxy.URL != "" {
+		proxyURL, err := url.Parse(proxy.URL)
+		if err != nil {
+			return nil, Errorf(ErrorKindValidation, "error parsing proxy url, error: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	} else {
+		var rules []app.ProxyRule
+		if a.Config != nil {
+			rules = a.Config.Proxies
+		}
+		transport.Proxy = func(r *http.Request) (*url.URL, error) {
+			return GetProxyURL(r.URL, rules)
+		}
+	}
+
+	if a.Jar != nil {
+		client.Jar = a.Jar
+	} else if a.Config != nil && a.Config.CookieJar {
+		jar, err := LoadCookieJar(CookieJarPath(a.Config))
+		if err != nil {
+			return nil, Errorf(ErrorKindFile, "error loading cookie jar %w", err)
+		}
+		client.Jar = jar
+	}
+
+	tlsConfig, err := newTLSConfig(requestTLS(req, a))
+	if err != nil {
+		return nil, NewError(ErrorKindTLS, err)
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	timeout := requestTimeout(req, a)
+	client.Timeout = timeout.Total
+	if timeout.Connect > 0 {
+		dialer := &net.Dialer{Timeout: timeout.Connect, KeepAlive: 30 * time.Second}
+		transport.DialContext = dialer.DialContext
+	}
+	if timeout.TLSHandshake > 0 {
+		transport.TLSHandshakeTimeout = timeout.TLSHandshake
+	}
+	transport.ResponseHeaderTimeout = timeout.ResponseHeader
+
+	return client, nil
+}
+
+// requestTimeout merges timeout of the request with defaults from config.
+func requestTimeout(req *Request, a *app.App) app.Timeout {
+	var timeout app.Timeout
+	if a.Config != nil && a.Config.Timeout != nil {
+		timeout = *a.Config.Timeout
+	}
+	if req.Timeout == nil {
+		return timeout
+	}
+	if req.Timeout.Total != 0 {
+		timeout.Total = req.Timeout.Total
+	}
+	if req.Timeout.Connect != 0 {
+		timeout.Connect = req.Timeout.Connect
+	}
+	if req.Timeout.TLSHandshake != 0 {
+		timeout.TLSHandshake = req.Timeout.TLSHandshake
+	}
+	if req.Timeout.ResponseHeader != 0 {
+		timeout.ResponseHeader = req.Timeout.ResponseHeader
+	}
+	return timeout
+}
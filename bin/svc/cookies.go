@@ -0,0 +1,273 @@
+package svc
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shrijan00003/restler/core/app"
+	"github.com/shrijan00003/restler/core/utils"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Cookie is the cookie stored in the jar file.
+type Cookie struct {
+	Name     string     `json:"name"`
+	Value    string     `json:"value"`
+	Domain   string     `json:"domain"`
+	Path     string     `json:"path"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	Secure   bool       `json:"secure,omitempty"`
+	HttpOnly bool       `json:"httpOnly,omitempty"`
+	// HostOnly cookies are only sent to the exact host which set them.
+	HostOnly bool `json:"hostOnly,omitempty"`
+}
+
+// CookieJar is http.CookieJar which is persisted in a json file, it follows
+// domain, path and expiry rules of RFC 6265.
+type CookieJar struct {
+	mu      sync.Mutex
+	path    string
+	cookies []Cookie
+}
+
+// CookieJarPath returns path of the jar for the env of the project eg.
+// .restler/cookies.default.json
+func CookieJarPath(config *app.Config) string {
+	envName := "default"
+	if config != nil && config.Env != "" {
+		envName = config.Env
+	}
+	return filepath.Join(utils.Pwd(), ".restler", "cookies."+envName+".json")
+}
+
+// LoadCookieJar loads the jar from the path, missing file is an empty jar.
+func LoadCookieJar(path string) (*CookieJar, error) {
+	jar := &CookieJar{path: path}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return jar, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &jar.cookies); err != nil {
+		return nil, err
+	}
+	jar.removeExpired(time.Now())
+	return jar, nil
+}
+
+func (j *CookieJar) Save() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(j.cookies, "", "  ")
+	if err != nil {
+		return err
+	}
+	// cookies are credentials, keep them private
+	return os.WriteFile(j.path, content, 0600)
+}
+
+// All returns stored cookies which are not expired.
+func (j *CookieJar) All() []Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.removeExpired(time.Now())
+	return append([]Cookie(nil), j.cookies...)
+}
+
+// Delete removes cookies by name, domain is optional, returns count of
+// removed cookies.
+func (j *CookieJar) Delete(name string, domain string) int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	domain = strings.TrimPrefix(strings.ToLower(domain), ".")
+	kept := j.cookies[:0]
+	for _, c := range j.cookies {
+		if c.Name == name && (domain == "" || c.Domain == domain) {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	removed := len(j.cookies) - len(kept)
+	j.cookies = kept
+	return removed
+}
+
+func (j *CookieJar) Clear() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.cookies = nil
+}
+
+// SetCookies implements http.CookieJar
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	host := canonicalHost(u.Host)
+
+	for _, hc := range cookies {
+		c := Cookie{
+			Name:     hc.Name,
+			Value:    hc.Value,
+			Path:     hc.Path,
+			Secure:   hc.Secure,
+			HttpOnly: hc.HttpOnly,
+		}
+
+		domain, hostOnly, ok := cookieDomain(host, hc.Domain)
+		if !ok {
+			continue
+		}
+		c.Domain, c.HostOnly = domain, hostOnly
+
+		if c.Path == "" || c.Path[0] != '/' {
+			c.Path = defaultCookiePath(u.Path)
+		}
+
+		expired := false
+		switch {
+		case hc.MaxAge < 0:
+			expired = true
+		case hc.MaxAge > 0:
+			expires := now.Add(time.Duration(hc.MaxAge) * time.Second)
+			c.Expires = &expires
+		case !hc.Expires.IsZero():
+			expires := hc.Expires
+			expired = !expires.After(now)
+			c.Expires = &expires
+		}
+
+		j.remove(c.Name, c.Domain, c.Path)
+		if !expired {
+			j.cookies = append(j.cookies, c)
+		}
+	}
+}
+
+// Cookies implements http.CookieJar
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.removeExpired(time.Now())
+
+	host := canonicalHost(u.Host)
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	secure := u.Scheme == "https" || u.Scheme == "wss"
+
+	var matched []Cookie
+	for _, c := range j.cookies {
+		if c.Secure && !secure {
+			continue
+		}
+		if !domainMatch(c, host) || !pathMatch(c.Path, path) {
+			continue
+		}
+		matched = append(matched, c)
+	}
+
+	// cookies with longer paths are sent first
+	sort.SliceStable(matched, func(a, b int) bool {
+		return len(matched[a].Path) > len(matched[b].Path)
+	})
+
+	result := make([]*http.Cookie, 0, len(matched))
+	for _, c := range matched {
+		result = append(result, &http.Cookie{Name: c.Name, Value: c.Value})
+	}
+	return result
+}
+
+func (j *CookieJar) remove(name string, domain string, path string) {
+	for i, c := range j.cookies {
+		if c.Name == name && c.Domain == domain && c.Path == path {
+			j.cookies = append(j.cookies[:i], j.cookies[i+1:]...)
+			return
+		}
+	}
+}
+
+func (j *CookieJar) removeExpired(now time.Time) {
+	kept := j.cookies[:0]
+	for _, c := range j.cookies {
+		if c.Expires != nil && !c.Expires.After(now) {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	j.cookies = kept
+}
+
+func canonicalHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// cookieDomain returns the domain of the cookie set by host, cookies for
+// other domains and public suffixes (eg. co.uk) are rejected.
+func cookieDomain(host string, domain string) (string, bool, bool) {
+	if domain == "" {
+		return host, true, true
+	}
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	if net.ParseIP(host) != nil {
+		// ip address can only set host only cookies
+		return host, true, host == domain
+	}
+	if domain == host {
+		return domain, false, true
+	}
+	if !strings.HasSuffix(host, "."+domain) {
+		return "", false, false
+	}
+	if suffix, _ := publicsuffix.PublicSuffix(domain); suffix == domain {
+		return "", false, false
+	}
+	return domain, false, true
+}
+
+func domainMatch(c Cookie, host string) bool {
+	if c.HostOnly {
+		return host == c.Domain
+	}
+	return host == c.Domain || strings.HasSuffix(host, "."+c.Domain)
+}
+
+func pathMatch(cookiePath string, path string) bool {
+	if cookiePath == path {
+		return true
+	}
+	if !strings.HasPrefix(path, cookiePath) {
+		return false
+	}
+	return strings.HasSuffix(cookiePath, "/") || path[len(cookiePath)] == '/'
+}
+
+// defaultCookiePath is the directory of the request path as per RFC 6265
+func defaultCookiePath(path string) string {
+	if path == "" || path[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndex(path, "/")
+	if i == 0 {
+		return "/"
+	}
+	return path[:i]
+}
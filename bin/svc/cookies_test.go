@@ -0,0 +1,223 @@
+package svc
+
+import (
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func cookieNames(cookies []*http.Cookie) string {
+	names := make([]string, 0, len(cookies))
+	for _, c := range cookies {
+		names = append(names, c.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestCookieDomain(t *testing.T) {
+	tests := []struct {
+		host, domain string
+		want         string
+		hostOnly, ok bool
+	}{
+		{"api.example.com", "", "api.example.com", true, true},
+		{"api.example.com", "example.com", "example.com", false, true},
+		{"api.example.com", ".Example.com", "example.com", false, true},
+		{"api.example.com", "api.example.com", "api.example.com", false, true},
+		{"api.example.com", "other.com", "", false, false},
+		{"api.example.com", "ample.com", "", false, false},
+		{"api.example.co.uk", "co.uk", "", false, false},
+		{"127.0.0.1", "127.0.0.1", "127.0.0.1", true, true},
+		{"127.0.0.1", "0.0.1", "127.0.0.1", true, false},
+	}
+	for _, tt := range tests {
+		domain, hostOnly, ok := cookieDomain(tt.host, tt.domain)
+		if ok != tt.ok || ok && (domain != tt.want || hostOnly != tt.hostOnly) {
+			t.Errorf("cookieDomain(%q, %q) = %q, %v, %v, want %q, %v, %v",
+				tt.host, tt.domain, domain, hostOnly, ok, tt.want, tt.hostOnly, tt.ok)
+		}
+	}
+}
+
+func TestDomainMatch(t *testing.T) {
+	tests := []struct {
+		cookie Cookie
+		host   string
+		want   bool
+	}{
+		{Cookie{Domain: "example.com"}, "example.com", true},
+		{Cookie{Domain: "example.com"}, "api.example.com", true},
+		{Cookie{Domain: "example.com"}, "badexample.com", false},
+		{Cookie{Domain: "example.com", HostOnly: true}, "example.com", true},
+		{Cookie{Domain: "example.com", HostOnly: true}, "api.example.com", false},
+	}
+	for _, tt := range tests {
+		if got := domainMatch(tt.cookie, tt.host); got != tt.want {
+			t.Errorf("domainMatch(%+v, %q) = %v, want %v", tt.cookie, tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestPathMatch(t *testing.T) {
+	tests := []struct {
+		cookiePath, path string
+		want             bool
+	}{
+		{"/", "/", true},
+		{"/", "/posts", true},
+		{"/posts", "/posts", true},
+		{"/posts", "/posts/1", true},
+		{"/posts/", "/posts/1", true},
+		{"/posts", "/postsx", false},
+		{"/posts", "/", false},
+	}
+	for _, tt := range tests {
+		if got := pathMatch(tt.cookiePath, tt.path); got != tt.want {
+			t.Errorf("pathMatch(%q, %q) = %v, want %v", tt.cookiePath, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultCookiePath(t *testing.T) {
+	tests := map[string]string{
+		"":             "/",
+		"posts":        "/",
+		"/":            "/",
+		"/login":       "/",
+		"/api/login":   "/api",
+		"/api/v1/auth": "/api/v1",
+	}
+	for path, want := range tests {
+		if got := defaultCookiePath(path); got != want {
+			t.Errorf("defaultCookiePath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestCookieJar(t *testing.T) {
+	jar := &CookieJar{}
+	jar.SetCookies(mustURL(t, "https://api.example.com/auth/login"), []*http.Cookie{
+		{Name: "session", Value: "1"},
+		{Name: "shared", Value: "2", Domain: "example.com", Path: "/"},
+		{Name: "secure", Value: "3", Path: "/", Secure: true},
+		{Name: "admin", Value: "4", Path: "/admin"},
+		{Name: "evil", Value: "5", Domain: "other.com"},
+	})
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://api.example.com/auth/me", "session,shared,secure"},
+		{"http://api.example.com/auth/me", "session,shared"},
+		{"https://api.example.com/admin/users", "admin,shared,secure"},
+		{"https://www.example.com/", "shared"},
+		{"https://other.com/", ""},
+	}
+	for _, tt := range tests {
+		if got := cookieNames(jar.Cookies(mustURL(t, tt.url))); got != tt.want {
+			t.Errorf("Cookies(%s) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestCookieJarExpiry(t *testing.T) {
+	u := mustURL(t, "https://example.com/")
+	jar := &CookieJar{}
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "a", Value: "1", MaxAge: 60},
+		{Name: "b", Value: "2", Expires: time.Now().Add(-time.Hour)},
+		{Name: "c", Value: "3"},
+	})
+	if got := cookieNames(jar.Cookies(u)); got != "a,c" {
+		t.Fatalf("Cookies = %q, want a,c", got)
+	}
+
+	// cookie is replaced by name, domain and path, negative max age deletes it
+	jar.SetCookies(u, []*http.Cookie{{Name: "c", Value: "new"}, {Name: "a", MaxAge: -1}})
+	cookies := jar.Cookies(u)
+	if len(cookies) != 1 || cookies[0].Name != "c" || cookies[0].Value != "new" {
+		t.Errorf("Cookies = %v, want c=new", cookies)
+	}
+}
+
+func TestCookieJarSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".restler", "cookies.default.json")
+	u := mustURL(t, "https://example.com/")
+
+	jar, err := LoadCookieJar(path)
+	if err != nil {
+		t.Fatalf("LoadCookieJar of missing file: %v", err)
+	}
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "session", Value: "1", MaxAge: 60},
+		{Name: "other", Value: "2"},
+	})
+	if err := jar.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := LoadCookieJar(path)
+	if err != nil {
+		t.Fatalf("LoadCookieJar: %v", err)
+	}
+	if got := cookieNames(loaded.Cookies(u)); got != "session,other" {
+		t.Errorf("loaded cookies = %q, want session,other", got)
+	}
+	if removed := loaded.Delete("other", ".Example.com"); removed != 1 {
+		t.Errorf("Delete removed %d cookies, want 1", removed)
+	}
+	if all := loaded.All(); len(all) != 1 || all[0].Name != "session" {
+		t.Errorf("All = %v, want session", all)
+	}
+}
+
+func TestCookieJarConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.default.json")
+	jar, err := LoadCookieJar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// requests of restler test share the jar and save it after every request
+	names := []string{"a", "b", "c", "d", "e", "f"}
+	done := make(chan struct{})
+	for _, name := range names {
+		go func(name string) {
+			jar.SetCookies(mustURL(t, "https://example.com/"), []*http.Cookie{{Name: name, Value: name}})
+			if err := jar.Save(); err != nil {
+				t.Error(err)
+			}
+			done <- struct{}{}
+		}(name)
+	}
+	for range names {
+		<-done
+	}
+
+	loaded, err := LoadCookieJar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved []string
+	for _, c := range loaded.All() {
+		saved = append(saved, c.Name)
+	}
+	sort.Strings(saved)
+	if got := strings.Join(saved, ","); got != "a,b,c,d,e,f" {
+		t.Errorf("saved cookies = %q, want a,b,c,d,e,f", got)
+	}
+}
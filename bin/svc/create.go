@@ -28,7 +28,7 @@ var restlerPath = os.Getenv("RESTLER_PATH")
 // +++++++++++++++++++++++++
 func createRestlerCollection(c *cli.Context) error {
 	restlerPath := os.Getenv("RESTLER_PATH")
-	fmt.Println("[Restler Log]: Creating restler collection", c.Args().First())
+	fmt.Fprintln(os.Stderr, "[Restler Log]: Creating restler collection", c.Args().First())
 	// collection is basically restler structure
 	// it will have env folder, config.yaml and sample request
 	collectionPath := fmt.Sprintf("%s/%s", restlerPath, c.Args().First())
@@ -39,12 +39,12 @@ func createRestlerCollection(c *cli.Context) error {
 		}
 		err = createDefaultFiles(collectionPath)
 		if err != nil {
-			fmt.Println("[error]: Error occurred while creating default files: ", err)
+			fmt.Fprintln(os.Stderr, "[error]: Error occurred while creating default files: ", err)
 			return err
 		}
 		return nil
 	} else {
-		fmt.Println("[info]: path exists, ignoring create restler collection")
+		fmt.Fprintln(os.Stderr, "[info]: path exists, ignoring create restler collection")
 	}
 	return nil
 }
@@ -208,16 +208,16 @@ func createDefaultFiles(path string) error {
 
 	file, err := os.OpenFile(".gitignore", os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
-		fmt.Println("[error]: Error occurred while opening .gitignore file: ", err)
+		fmt.Fprintln(os.Stderr, "[error]: Error occurred while opening .gitignore file: ", err)
 		return err
 	}
 	defer file.Close()
 
 	// TODO: update only if its not available on the .gitignore
-	fileContent := "# Ignore response file\n**/.*.res.md\n\n# Ignore .env file\n.env\n.env.local\n"
+	fileContent := "# Ignore response files\n**/.*.res.*\n\n# Ignore .env file\n.env\n.env.local\n\n# Ignore cookies\n.restler\n"
 	_, err = file.WriteString(fileContent)
 	if err != nil {
-		fmt.Println("[error]: Error occurred while writing to .gitignore file: ", err)
+		fmt.Fprintln(os.Stderr, "[error]: Error occurred while writing to .gitignore file: ", err)
 		return err
 	}
 
@@ -0,0 +1,198 @@
+package svc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+)
+
+// ErrorKind is the category of the error, every kind has its own exit code so
+// that scripts and CI can tell what went wrong, see docs/errors.md.
+type ErrorKind string
+
+const (
+	ErrorKindUsage      ErrorKind = "usage"      // invalid arguments or flags
+	ErrorKindFile       ErrorKind = "file"       // request file can't be read or response file can't be written
+	ErrorKindParse      ErrorKind = "parse"      // request file is not valid yaml
+	ErrorKindTemplate   ErrorKind = "template"   // undefined or invalid template variables
+	ErrorKindValidation ErrorKind = "validation" // request is not valid eg. url, method, body
+	ErrorKindTransport  ErrorKind = "transport"  // network error while sending the request
+	ErrorKindTimeout    ErrorKind = "timeout"    // request timed out
+	ErrorKindTLS        ErrorKind = "tls"        // tls handshake or certificate error
+	ErrorKindResponse   ErrorKind = "response"   // response can't be read or decoded
+	ErrorKindAssertion  ErrorKind = "assertion"  // response doesn't match the Assert section
+)
+
+// ExitCodeError is used for errors which are not *Error.
+const ExitCodeError = 1
+
+var exitCodes = map[ErrorKind]int{
+	ErrorKindUsage:      2,
+	ErrorKindFile:       10,
+	ErrorKindParse:      11,
+	ErrorKindTemplate:   12,
+	ErrorKindValidation: 13,
+	ErrorKindTransport:  20,
+	ErrorKindTimeout:    21,
+	ErrorKindTLS:        22,
+	ErrorKindResponse:   23,
+	ErrorKindAssertion:  30,
+}
+
+// Error is an error of restler with its kind and the file it is related to,
+// line and column are set when the error is at a position of the file.
+type Error struct {
+	Kind   ErrorKind
+	Path   string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Path != "" && e.Line > 0:
+		return fmt.Sprintf("%s:%d:%d: %s", e.Path, e.Line, e.Column, e.Err)
+	case e.Path != "":
+		return fmt.Sprintf("%s: %s", e.Path, e.Err)
+	}
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) ExitCode() int {
+	if code, ok := exitCodes[e.Kind]; ok {
+		return code
+	}
+	return ExitCodeError
+}
+
+// NewError returns err with the kind, kind of err is kept if it already is an
+// *Error, and missing files are always ErrorKindFile.
+func NewError(kind ErrorKind, err error) error {
+	if err == nil {
+		return nil
+	}
+	var restlerErr *Error
+	if errors.As(err, &restlerErr) {
+		return err
+	}
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+		kind = ErrorKindFile
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// Errorf formats the error with the kind, like fmt.Errorf.
+func Errorf(kind ErrorKind, format string, args ...interface{}) error {
+	return NewError(kind, fmt.Errorf(format, args...))
+}
+
+// ErrorKindOf returns kind of the error, empty for errors which are not *Error.
+func ErrorKindOf(err error) ErrorKind {
+	var restlerErr *Error
+	if errors.As(err, &restlerErr) {
+		return restlerErr.Kind
+	}
+	return ""
+}
+
+// ExitCode returns exit code of the error, errors with their own exit code
+// like cli.Exit are honored.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return ExitCodeError
+}
+
+// Error formats of --error-format flag.
+const (
+	ErrorFormatText = "text"
+	ErrorFormatJSON = "json"
+)
+
+type errorOutput struct {
+	Kind     ErrorKind `json:"kind"`
+	Message  string    `json:"message"`
+	Path     string    `json:"path,omitempty"`
+	Line     int       `json:"line,omitempty"`
+	Column   int       `json:"column,omitempty"`
+	ExitCode int       `json:"exitCode"`
+}
+
+// WriteError writes the error as text or a single line of json for CI, errors
+// without message (eg. exit code of response status) are not written. Every
+// error of ErrorList is written in its own line.
+func WriteError(w io.Writer, err error, format string) {
+	if err == nil || err.Error() == "" {
+		return
+	}
+	var list ErrorList
+	if errors.As(err, &list) {
+		for _, listErr := range list {
+			WriteError(w, listErr, format)
+		}
+		return
+	}
+	if format != ErrorFormatJSON {
+		fmt.Fprintln(w, "[restler Error]:", err)
+		return
+	}
+
+	output := errorOutput{Kind: "error", Message: err.Error(), ExitCode: ExitCode(err)}
+	var restlerErr *Error
+	if errors.As(err, &restlerErr) {
+		output.Kind = restlerErr.Kind
+		output.Message = restlerErr.Err.Error()
+		output.Path = restlerErr.Path
+		output.Line = restlerErr.Line
+		output.Column = restlerErr.Column
+	}
+	json.NewEncoder(w).Encode(output)
+}
+
+// transportErrorKind separates timeouts and tls errors from other network
+// errors of the request.
+func transportErrorKind(err error) ErrorKind {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrorKindTimeout
+	}
+
+	if isTLSError(err) {
+		return ErrorKindTLS
+	}
+	return ErrorKindTransport
+}
+
+// isTLSError is true for handshake and certificate errors
+func isTLSError(err error) bool {
+	// alerts sent by the server are only available as remote error
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "remote error" {
+		return true
+	}
+
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var verifyErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	return errors.As(err, &recordErr) || errors.As(err, &alertErr) || errors.As(err, &verifyErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr)
+}
@@ -0,0 +1,67 @@
+package svc
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+)
+
+func TestNewError(t *testing.T) {
+	_, notExist := os.ReadFile("missing.yaml")
+	tests := []struct {
+		name     string
+		err      error
+		want     ErrorKind
+		wantCode int
+	}{
+		{"kind", Errorf(ErrorKindTemplate, "undefined variables: %s", "A"), ErrorKindTemplate, 12},
+		{"missing file", NewError(ErrorKindParse, notExist), ErrorKindFile, 10},
+		{"kind is kept", NewError(ErrorKindResponse, Errorf(ErrorKindTimeout, "timeout")), ErrorKindTimeout, 21},
+		{"wrapped", fmt.Errorf("running request: %w", Errorf(ErrorKindTLS, "bad certificate")), ErrorKindTLS, 22},
+		{"plain error", errors.New("unexpected"), "", ExitCodeError},
+	}
+	for _, tt := range tests {
+		if got := ErrorKindOf(tt.err); got != tt.want {
+			t.Errorf("%s: ErrorKindOf = %q, want %q", tt.name, got, tt.want)
+		}
+		if got := ExitCode(tt.err); got != tt.wantCode {
+			t.Errorf("%s: ExitCode = %d, want %d", tt.name, got, tt.wantCode)
+		}
+	}
+	if NewError(ErrorKindFile, nil) != nil {
+		t.Errorf("NewError of nil should be nil")
+	}
+}
+
+func TestTransportErrorKind(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{"deadline", fmt.Errorf("get: %w", context.DeadlineExceeded), ErrorKindTimeout},
+		{"unknown authority", fmt.Errorf("get: %w", x509.UnknownAuthorityError{}), ErrorKindTLS},
+		{"hostname", x509.HostnameError{Host: "example.com", Certificate: &x509.Certificate{}}, ErrorKindTLS},
+		{"remote alert", &net.OpError{Op: "remote error", Err: errors.New("tls: bad certificate")}, ErrorKindTLS},
+		{"connection refused", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, ErrorKindTransport},
+	}
+	for _, tt := range tests {
+		if got := transportErrorKind(tt.err); got != tt.want {
+			t.Errorf("%s: transportErrorKind = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWriteErrorJSON(t *testing.T) {
+	var buffer bytes.Buffer
+	WriteError(&buffer, Errorf(ErrorKindFile, "error writing response file %w", os.ErrPermission), "json")
+	want := `{"kind":"file","message":"error writing response file permission denied","exitCode":10}` + "\n"
+	if buffer.String() != want {
+		t.Errorf("WriteError = %s, want %s", buffer.String(), want)
+	}
+}
@@ -0,0 +1,424 @@
+package svc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PaesslerAG/jsonpath"
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+	"github.com/jmespath/go-jmespath"
+	"github.com/shrijan00003/restler/core/app"
+	"github.com/shrijan00003/restler/core/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// Extractor reads a value from the response for After.Env, it is either a
+// string like Body[data][token] or a map with the expression eg.
+//
+//	After:
+//	  Env:
+//	    TOKEN: Body[data][token]
+//	    DATE: Header[Date]
+//	    STATUS: Status
+//	    SESSION: Cookie[session]
+//	    ACTIVE_ID:
+//	      Body: $.items[?(@.active)].id     # JSONPath
+//	    LAST_ID:
+//	      Body: items[-1].id                # JMESPath
+//	    USER_ID:
+//	      Header: Authorization
+//	      Transform: jwt:sub
+//	      Default: anonymous
+type Extractor struct {
+	// Body is JSONPath when it starts with $, bracket path like [a][0] or
+	// JMESPath otherwise, empty Body: "" is the whole body.
+	Body     *string `yaml:"Body,omitempty"`
+	JSONPath string  `yaml:"JSONPath,omitempty"`
+	JMESPath string  `yaml:"JMESPath,omitempty"`
+	Header   string  `yaml:"Header,omitempty"`
+	// Regex is matched on the body, the value is the first group or the whole
+	// match when there are no groups. Group is the number or name of the group.
+	Regex string `yaml:"Regex,omitempty"`
+	Group string `yaml:"Group,omitempty"`
+	// XPath of XML or HTML body, Namespaces are the prefixes used in XPath.
+	XPath      string            `yaml:"XPath,omitempty"`
+	Namespaces map[string]string `yaml:"Namespaces,omitempty"`
+	Cookie     string            `yaml:"Cookie,omitempty"`
+	// Timing is one of dns, connect, tls, ttfb, transfer, total in ms.
+	Timing string `yaml:"Timing,omitempty"`
+	Status bool   `yaml:"Status,omitempty"`
+	// URL is the final url of the request after redirects.
+	URL bool `yaml:"URL,omitempty"`
+
+	// Transform steps separated by | eg. trim | base64-decode | jwt:sub
+	Transform string `yaml:"Transform,omitempty"`
+	// Default is used when the value can't be extracted.
+	Default *string `yaml:"Default,omitempty"`
+
+	// raw is the string form, it is kept to write the request back as it is
+	raw string
+}
+
+// plainExtractor is decoded without UnmarshalYAML of Extractor
+type plainExtractor Extractor
+
+func (e *Extractor) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return e.parse(node.Value)
+	}
+	if err := node.Decode((*plainExtractor)(e)); err != nil {
+		return err
+	}
+	return e.validate()
+}
+
+func (e *Extractor) MarshalYAML() (interface{}, error) {
+	if e.raw != "" {
+		return e.raw, nil
+	}
+	return (*plainExtractor)(e), nil
+}
+
+// parse reads string form of the extractor ie. Body[a][b], Header[Name],
+// Cookie[name], Timing[total], Status or URL
+func (e *Extractor) parse(value string) error {
+	e.raw = value
+	switch {
+	case value == "Status":
+		e.Status = true
+	case value == "URL":
+		e.URL = true
+	case strings.HasPrefix(value, "Body"):
+		body := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(value, "Body"), ":"))
+		e.Body = &body
+	case strings.HasPrefix(value, "Header"):
+		e.Header = bracketArg(value, "Header")
+	case strings.HasPrefix(value, "Cookie"):
+		e.Cookie = bracketArg(value, "Cookie")
+	case strings.HasPrefix(value, "Timing"):
+		e.Timing = bracketArg(value, "Timing")
+	default:
+		return fmt.Errorf("unsupported value %q, use Body[path], Header[Name], Cookie[name], Timing[name], Status or URL", value)
+	}
+	return e.validate()
+}
+
+// bracketArg returns name of Header[Name] or Header: Name
+func bracketArg(value string, prefix string) string {
+	arg := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(value, prefix), ":"))
+	return strings.TrimSuffix(strings.TrimPrefix(arg, "["), "]")
+}
+
+// validate checks that the extractor has a single source and its expressions
+// compile, so that the request file is reported before it is sent.
+func (e *Extractor) validate() error {
+	sources := 0
+	for _, set := range []bool{e.Body != nil, e.JSONPath != "", e.JMESPath != "", e.Header != "", e.Regex != "",
+		e.XPath != "", e.Cookie != "", e.Timing != "", e.Status, e.URL} {
+		if set {
+			sources++
+		}
+	}
+	switch {
+	case sources == 0:
+		return fmt.Errorf("extractor should have one of Body, JSONPath, JMESPath, Header, Regex, XPath, Cookie, Timing, Status or URL")
+	case sources > 1:
+		return fmt.Errorf("extractor should have only one of Body, JSONPath, JMESPath, Header, Regex, XPath, Cookie, Timing, Status or URL")
+	}
+
+	if e.Regex != "" {
+		if _, err := e.regexGroup(); err != nil {
+			return err
+		}
+	} else if e.Group != "" {
+		return fmt.Errorf("Group can only be used with Regex")
+	}
+	if e.XPath != "" {
+		if _, err := xpath.CompileWithNS(e.XPath, e.Namespaces); err != nil {
+			return fmt.Errorf("invalid XPath %q: %w", e.XPath, err)
+		}
+	}
+	if e.Timing != "" {
+		if _, ok := (app.Timing{}).Values()[e.Timing]; !ok {
+			return fmt.Errorf("unknown Timing %s, use one of %s", e.Timing, strings.Join(timingNames(), ", "))
+		}
+	}
+	if e.Transform != "" {
+		if _, err := transformSteps(e.Transform); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrValueNotFound is returned when the expression doesn't match the response.
+var ErrValueNotFound = fmt.Errorf("value not found")
+
+// bracketPath is the path syntax of utils.GetNestedValue eg. [data][0][id]
+var bracketPath = regexp.MustCompile(`^(\[[^\[\]]*\])+$`)
+
+// ExtractEnv extracts values of After.Env from the response, values which
+// can't be extracted are empty and their errors are returned by env name.
+func ExtractEnv(env map[string]*Extractor, res *Response) (map[string]string, map[string]error) {
+	values := make(map[string]string, len(env))
+	errs := map[string]error{}
+	body := &jsonBody{raw: res.Body}
+	for name, extractor := range env {
+		if extractor == nil {
+			continue
+		}
+		value, err := extractor.extract(res, body)
+		if err != nil {
+			errs[name] = err
+		}
+		values[name] = value
+	}
+	return values, errs
+}
+
+// jsonBody is parsed once for all the extractors
+type jsonBody struct {
+	raw    []byte
+	parsed bool
+	value  interface{}
+	err    error
+}
+
+func (b *jsonBody) get() (interface{}, error) {
+	if !b.parsed {
+		b.parsed = true
+		// top level arrays and scalars are supported as well
+		if err := json.Unmarshal(b.raw, &b.value); err != nil {
+			b.err = fmt.Errorf("body is not in JSON format: %w", err)
+		}
+	}
+	return b.value, b.err
+}
+
+// extract returns the transformed value, Default is returned when the value
+// can't be extracted or transformed.
+func (e *Extractor) extract(res *Response, body *jsonBody) (string, error) {
+	value, err := e.extractSource(res, body)
+	if err == nil && e.Transform != "" {
+		value, err = transform(e.Transform, value)
+	}
+	if err != nil && e.Default != nil {
+		return *e.Default, nil
+	}
+	return value, err
+}
+
+func (e *Extractor) extractSource(res *Response, body *jsonBody) (string, error) {
+	switch {
+	case e.Status:
+		return strconv.Itoa(res.HTTP.StatusCode), nil
+	case e.URL:
+		return res.HTTP.Request.URL.String(), nil
+	case e.Header != "":
+		values := res.HTTP.Header.Values(e.Header)
+		if len(values) == 0 {
+			return "", fmt.Errorf("header %s: %w", e.Header, ErrValueNotFound)
+		}
+		return strings.Join(values, ", "), nil
+	case e.Cookie != "":
+		return cookieValue(res, e.Cookie)
+	case e.Timing != "":
+		d, ok := res.Timing.Values()[e.Timing]
+		if !ok {
+			return "", fmt.Errorf("timing %s: %w", e.Timing, ErrValueNotFound)
+		}
+		return strconv.FormatFloat(milliseconds(d), 'f', -1, 64), nil
+	case e.Regex != "":
+		return e.regexValue(res.Body)
+	case e.XPath != "":
+		return e.xpathValue(res)
+	case e.JSONPath != "":
+		return extractJSON(body, e.JSONPath, jsonPathValue)
+	case e.JMESPath != "":
+		return extractJSON(body, e.JMESPath, jmesPathValue)
+	case e.Body != nil:
+		if *e.Body == "" {
+			return string(res.Body), nil
+		}
+		return extractJSON(body, *e.Body, pathValue)
+	}
+	return "", e.validate()
+}
+
+func extractJSON(body *jsonBody, expr string, eval func(string, interface{}) (interface{}, error)) (string, error) {
+	data, err := body.get()
+	if err != nil {
+		return "", err
+	}
+	value, err := eval(expr, data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", expr, err)
+	}
+	if value == nil {
+		return "", fmt.Errorf("%s: %w", expr, ErrValueNotFound)
+	}
+	return formatValue(value), nil
+}
+
+// pathValue evaluates JSONPath when the path starts with $, bracket path like
+// [a][0] or JMESPath otherwise.
+func pathValue(expr string, data interface{}) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(expr, "$"):
+		return jsonPathValue(expr, data)
+	case bracketPath.MatchString(expr):
+		return bracketValue(expr, data)
+	default:
+		return jmesPathValue(expr, data)
+	}
+}
+
+func bracketValue(expr string, data interface{}) (interface{}, error) {
+	value, ok := utils.GetNestedValue(data, expr)
+	if !ok {
+		return nil, ErrValueNotFound
+	}
+	return value, nil
+}
+
+func jmesPathValue(expr string, data interface{}) (interface{}, error) {
+	return jmespath.Search(expr, data)
+}
+
+func jsonPathValue(expr string, data interface{}) (interface{}, error) {
+	value, err := jsonpath.Get(expr, data)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "unknown key") || strings.HasSuffix(err.Error(), "out of bounds") {
+			return nil, ErrValueNotFound
+		}
+		return nil, err
+	}
+	// filters and wildcards always return a list, single match is unwrapped
+	// so that $.items[?(@.active)].id gives the id instead of [id]
+	if list, ok := value.([]interface{}); ok && isIndefinitePath(expr) {
+		switch len(list) {
+		case 0:
+			return nil, nil
+		case 1:
+			return list[0], nil
+		}
+	}
+	return value, nil
+}
+
+// regexGroup returns index of the group which is the value of Regex
+func (e *Extractor) regexGroup() (int, error) {
+	re, err := regexp.Compile(e.Regex)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Regex %q: %w", e.Regex, err)
+	}
+	if e.Group == "" {
+		return min(1, re.NumSubexp()), nil
+	}
+	group, err := strconv.Atoi(e.Group)
+	if err != nil {
+		group = re.SubexpIndex(e.Group)
+	}
+	if group < 0 || group > re.NumSubexp() {
+		return 0, fmt.Errorf("Regex %q doesn't have group %s", e.Regex, e.Group)
+	}
+	return group, nil
+}
+
+func (e *Extractor) regexValue(body []byte) (string, error) {
+	group, err := e.regexGroup()
+	if err != nil {
+		return "", err
+	}
+	match := regexp.MustCompile(e.Regex).FindSubmatch(body)
+	if match == nil || match[group] == nil {
+		return "", fmt.Errorf("%s: %w", e.Regex, ErrValueNotFound)
+	}
+	return string(match[group]), nil
+}
+
+// xpathValue evaluates XPath on HTML or XML body, node sets give text of the
+// first node and functions like count() give their result.
+func (e *Extractor) xpathValue(res *Response) (string, error) {
+	expr, err := xpath.CompileWithNS(e.XPath, e.Namespaces)
+	if err != nil {
+		return "", fmt.Errorf("invalid XPath %q: %w", e.XPath, err)
+	}
+
+	var nav xpath.NodeNavigator
+	if strings.Contains(res.Media.MediaType, "html") {
+		doc, err := htmlquery.Parse(bytes.NewReader(res.Body))
+		if err != nil {
+			return "", fmt.Errorf("body is not in HTML format: %w", err)
+		}
+		nav = htmlquery.CreateXPathNavigator(doc)
+	} else {
+		doc, err := xmlquery.Parse(bytes.NewReader(res.Body))
+		if err != nil {
+			return "", fmt.Errorf("body is not in XML format: %w", err)
+		}
+		nav = xmlquery.CreateXPathNavigator(doc)
+	}
+
+	switch value := expr.Evaluate(nav).(type) {
+	case *xpath.NodeIterator:
+		if !value.MoveNext() {
+			return "", fmt.Errorf("%s: %w", e.XPath, ErrValueNotFound)
+		}
+		return strings.TrimSpace(value.Current().Value()), nil
+	default:
+		return formatValue(value), nil
+	}
+}
+
+// cookieValue returns the cookie set by the response or by the redirects
+// before it, the last one wins.
+func cookieValue(res *Response, name string) (string, error) {
+	for r := res.HTTP; r != nil; r = r.Request.Response {
+		for _, cookie := range r.Cookies() {
+			if cookie.Name == name {
+				return cookie.Value, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("cookie %s: %w", name, ErrValueNotFound)
+}
+
+func timingNames() []string {
+	names := make([]string, 0, 6)
+	for name := range (app.Timing{}).Values() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isIndefinitePath(expr string) bool {
+	return strings.Contains(expr, "?(") || strings.Contains(expr, "*") || strings.Contains(expr, "..") ||
+		strings.ContainsAny(expr, ",:")
+}
+
+// formatValue formats the value for env file, maps and lists are json
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(encoded)
+	}
+}
@@ -0,0 +1,298 @@
+package svc
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shrijan00003/restler/core/app"
+	"gopkg.in/yaml.v3"
+)
+
+const extractBody = `{
+  "data": {"token": "abc", "count": 2, "ratio": 0.5, "admin": false},
+  "items": [
+    {"id": 1, "name": "a", "active": false},
+    {"id": 2, "name": "b", "active": true},
+    {"id": 3, "name": "c", "active": true}
+  ],
+  "address": {"street": "Main St", "tags": ["x", "y"]}
+}`
+
+func extractResponse(body string) *Response {
+	header := http.Header{}
+	header.Add("Set-Cookie", "a=1")
+	header.Add("Set-Cookie", "b=2")
+	header.Set("Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+	return &Response{HTTP: &http.Response{Header: header}, Body: []byte(body)}
+}
+
+func parseExtractors(t *testing.T, content string) map[string]*Extractor {
+	t.Helper()
+	var env map[string]*Extractor
+	if err := yaml.Unmarshal([]byte(content), &env); err != nil {
+		t.Fatal(err)
+	}
+	return env
+}
+
+func TestExtractEnv(t *testing.T) {
+	env := parseExtractors(t, `
+TOKEN: Body[data][token]
+COUNT: Body[data][count]
+RATIO: Body[data][ratio]
+ADMIN: Body[data][admin]
+SECOND: Body[items][1][name]
+ADDRESS: Body[address]
+DATE: Header[Date]
+COOKIES: Header[Set-Cookie]
+ACTIVE_ID:
+  Body: $.items[?(@.name == "b")].id
+ACTIVE_IDS:
+  Body: $.items[?(@.active)].id
+ALL_NAMES:
+  JSONPath: $.items[*].name
+LAST_ID:
+  Body: items[-1].id
+ACTIVE_NAMES:
+  JMESPath: "items[?active].name"
+BRACKET:
+  Body: "[address][tags][1]"
+WHOLE:
+  Body: ""
+`)
+	values, errs := ExtractEnv(env, extractResponse(extractBody))
+	if len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	want := map[string]string{
+		"TOKEN":        "abc",
+		"COUNT":        "2",
+		"RATIO":        "0.5",
+		"ADMIN":        "false",
+		"SECOND":       "b",
+		"ADDRESS":      `{"street":"Main St","tags":["x","y"]}`,
+		"DATE":         "Mon, 02 Jan 2006 15:04:05 GMT",
+		"COOKIES":      "a=1, b=2",
+		"ACTIVE_ID":    "2",
+		"ACTIVE_IDS":   "[2,3]",
+		"ALL_NAMES":    `["a","b","c"]`,
+		"LAST_ID":      "3",
+		"ACTIVE_NAMES": `["b","c"]`,
+		"BRACKET":      "y",
+		"WHOLE":        extractBody,
+	}
+	for name, value := range want {
+		if values[name] != value {
+			t.Errorf("%s = %q, want %q", name, values[name], value)
+		}
+	}
+}
+
+func TestExtractTopLevelValues(t *testing.T) {
+	tests := []struct {
+		body string
+		expr string
+		want string
+	}{
+		{`[{"id": 7}]`, "$[0].id", "7"},
+		{`[{"id": 7}]`, "[0].id", "7"},
+		{`"token"`, "$", "token"},
+		{`42`, "@", "42"},
+	}
+	for _, tt := range tests {
+		expr := tt.expr
+		values, errs := ExtractEnv(map[string]*Extractor{"V": {Body: &expr}}, extractResponse(tt.body))
+		if errs["V"] != nil || values["V"] != tt.want {
+			t.Errorf("%s of %s = %q, %v, want %q", tt.expr, tt.body, values["V"], errs["V"], tt.want)
+		}
+	}
+}
+
+func TestExtractEnvErrors(t *testing.T) {
+	env := parseExtractors(t, `
+MISSING_KEY: Body[data][missing]
+MISSING_INDEX:
+  Body: $.items[10].id
+NO_MATCH:
+  Body: $.items[?(@.name == "z")].id
+JMES_MISSING:
+  JMESPath: data.missing
+MISSING_HEADER: Header[X-Missing]
+INVALID:
+  JSONPath: $.items[?(@.id >]
+`)
+	values, errs := ExtractEnv(env, extractResponse(extractBody))
+	for _, name := range []string{"MISSING_KEY", "MISSING_INDEX", "NO_MATCH", "JMES_MISSING", "MISSING_HEADER"} {
+		if !errors.Is(errs[name], ErrValueNotFound) {
+			t.Errorf("%s: error = %v, want value not found", name, errs[name])
+		}
+	}
+	if errs["INVALID"] == nil || errors.Is(errs["INVALID"], ErrValueNotFound) {
+		t.Errorf("INVALID: error = %v, want syntax error", errs["INVALID"])
+	}
+	for name, value := range values {
+		if value != "" {
+			t.Errorf("%s = %q, want empty value", name, value)
+		}
+	}
+
+	_, errs = ExtractEnv(parseExtractors(t, "TOKEN: Body[token]\n"), extractResponse("<html></html>"))
+	if errs["TOKEN"] == nil || !strings.Contains(errs["TOKEN"].Error(), "body is not in JSON format") {
+		t.Errorf("error of html body = %v, want not JSON error", errs["TOKEN"])
+	}
+}
+
+func TestExtractorYAML(t *testing.T) {
+	var env map[string]*Extractor
+	if err := yaml.Unmarshal([]byte("TOKEN: Query[session]\n"), &env); err == nil {
+		t.Errorf("unsupported extractor should be an error")
+	}
+
+	input := "ID:\n    JMESPath: items[0].id\nTOKEN: Body[data][token]\n"
+	output, err := yaml.Marshal(parseExtractors(t, input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) != input {
+		t.Errorf("extractors are written as\n%s\nwant\n%s", output, input)
+	}
+}
+
+func TestExtractSources(t *testing.T) {
+	redirect := &http.Response{
+		StatusCode: http.StatusFound,
+		Header:     http.Header{"Set-Cookie": {"session=first", "tracking=1"}},
+		Request:    &http.Request{URL: mustParseURL(t, "https://example.com/login")},
+	}
+	res := extractResponse(`<p>Order #1234 created, ref ABC-9</p>`)
+	res.HTTP.StatusCode = http.StatusCreated
+	res.HTTP.Header.Set("Set-Cookie", "session=final; Path=/")
+	res.HTTP.Request = &http.Request{URL: mustParseURL(t, "https://example.com/orders/1234"), Response: redirect}
+	res.Timing = app.Timing{TimeToFirstByte: 1500 * time.Microsecond, Total: 20 * time.Millisecond}
+
+	env := parseExtractors(t, `
+STATUS: Status
+FINAL_URL: URL
+SESSION: Cookie[session]
+TRACKING:
+  Cookie: tracking
+TTFB: Timing[ttfb]
+TOTAL:
+  Timing: total
+ORDER:
+  Regex: 'Order #(\d+)'
+WHOLE_MATCH:
+  Regex: 'ref [A-Z]+-\d'
+NAMED:
+  Regex: 'ref (?P<prefix>[A-Z]+)-(?P<number>\d)'
+  Group: number
+NUMBERED:
+  Regex: 'ref ([A-Z]+)-(\d)'
+  Group: "1"
+DEFAULTED:
+  Regex: 'Invoice #(\d+)'
+  Default: none
+TRANSFORMED:
+  Regex: 'ref ([A-Z]+)'
+  Transform: jwt:sub
+  Default: invalid
+`)
+	values, errs := ExtractEnv(env, res)
+	if len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	want := map[string]string{
+		"STATUS":      "201",
+		"FINAL_URL":   "https://example.com/orders/1234",
+		"SESSION":     "final",
+		"TRACKING":    "1",
+		"TTFB":        "1.5",
+		"TOTAL":       "20",
+		"ORDER":       "1234",
+		"WHOLE_MATCH": "ref ABC-9",
+		"NAMED":       "9",
+		"NUMBERED":    "ABC",
+		"DEFAULTED":   "none",
+		"TRANSFORMED": "invalid",
+	}
+	for name, value := range want {
+		if values[name] != value {
+			t.Errorf("%s = %q, want %q", name, values[name], value)
+		}
+	}
+}
+
+func TestExtractXPath(t *testing.T) {
+	soap := `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body>
+    <m:LoginResponse xmlns:m="urn:example">
+      <m:Token> abc </m:Token>
+      <m:Role>admin</m:Role>
+      <m:Role>user</m:Role>
+    </m:LoginResponse>
+  </soap:Body>
+</soap:Envelope>`
+	res := extractResponse(soap)
+	res.Media.MediaType = "text/xml"
+	env := parseExtractors(t, `
+TOKEN:
+  XPath: //soap:Body/m:LoginResponse/m:Token
+  Namespaces:
+    soap: http://schemas.xmlsoap.org/soap/envelope/
+    m: urn:example
+ROLE:
+  XPath: //*[local-name()='Role']
+ROLES:
+  XPath: count(//*[local-name()='Role'])
+MISSING:
+  XPath: //Missing
+`)
+	values, errs := ExtractEnv(env, res)
+	if values["TOKEN"] != "abc" || values["ROLE"] != "admin" || values["ROLES"] != "2" {
+		t.Errorf("values = %v, errors = %v", values, errs)
+	}
+	if !errors.Is(errs["MISSING"], ErrValueNotFound) {
+		t.Errorf("MISSING: error = %v, want value not found", errs["MISSING"])
+	}
+
+	res = extractResponse(`<html><body><form><input name="csrf" value="token-1"><h1>Title</h1></form></body></html>`)
+	res.Media.MediaType = "text/html"
+	env = parseExtractors(t, `
+CSRF:
+  XPath: //input[@name='csrf']/@value
+TITLE:
+  XPath: //h1
+`)
+	values, errs = ExtractEnv(env, res)
+	if values["CSRF"] != "token-1" || values["TITLE"] != "Title" {
+		t.Errorf("values = %v, errors = %v", values, errs)
+	}
+}
+
+func TestExtractorValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"no source", "V: {Default: a}", "extractor should have one of"},
+		{"two sources", "V: {Header: Date, Status: true}", "extractor should have only one of"},
+		{"invalid regex", "V: {Regex: '(a'}", "invalid Regex"},
+		{"unknown group", "V: {Regex: '(a)', Group: name}", "doesn't have group name"},
+		{"group without regex", "V: {Header: Date, Group: '1'}", "Group can only be used with Regex"},
+		{"invalid xpath", "V: {XPath: '//a['}", "invalid XPath"},
+		{"unknown timing", "V: Timing[latency]", "unknown Timing latency, use one of connect, dns, tls, total, transfer, ttfb"},
+		{"unknown transform", "V: {Header: Date, Transform: upper}", `unknown Transform "upper"`},
+	}
+	for _, tt := range tests {
+		var env map[string]*Extractor
+		err := yaml.Unmarshal([]byte(tt.content), &env)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -3,9 +3,13 @@ package svc
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 func findFileRecursively(startPath string, fileName string) (string, error) {
@@ -55,3 +59,75 @@ func getFileContent(url string) (string, error) {
 	}
 	return string(body), nil
 }
+
+// FindRequestFiles returns request files of the paths in lexical order, paths
+// can be files or directories. Hidden files and folders (eg. .res.* response
+// folders), the env folder, config.yaml and yaml files which aren't requests
+// are skipped in the directories.
+func FindRequestFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, NewError(ErrorKindFile, err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			name := entry.Name()
+			if file != path && strings.HasPrefix(name, ".") {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if entry.IsDir() {
+				if file != path && name == "env" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if name == "config.yaml" || name == "config.yml" {
+				return nil
+			}
+			if ext := filepath.Ext(name); (ext == ".yaml" || ext == ".yml") && isRequestFile(file) {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, NewError(ErrorKindFile, err)
+		}
+	}
+	return files, nil
+}
+
+// isRequestFile is false for yaml maps without any of Name, URL and Method eg.
+// env files, files which can't be parsed are requests so that their errors are
+// reported.
+func isRequestFile(file string) bool {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return true
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil || len(root.Content) == 0 {
+		return true
+	}
+	node := root.Content[0]
+	if node.Kind != yaml.MappingNode {
+		return true
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		switch node.Content[i].Value {
+		case "Name", "URL", "Method":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package svc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shrijan00003/restler/core/app"
+	"github.com/shrijan00003/restler/core/utils"
+)
+
+// Response is the processed response of a request, it has everything needed
+// by the formatters to write the response files.
+type Response struct {
+	Version   string // version of restler
+	Request   *Request
+	HTTP      *http.Response
+	StartedAt time.Time
+	Timing    app.Timing
+	Attempts  []app.Attempt
+
+	RawBody  []byte // as received from the server, possibly compressed
+	Body     []byte // decompressed and transcoded to utf-8
+	Display  []byte // pretty printed unless raw body is asked
+	Media    utils.Media
+	BodyFile string // file name of the binary body saved next to the response file
+	File     string // path of the response files without extension
+
+	Assertions AssertResults // results of the Assert section of the request
+	// SchemaViolations of the body against Schema of the request
+	SchemaViolations []SchemaViolation
+}
+
+// Formatter renders the response to a response file.
+type Formatter interface {
+	// Extension of the response file eg. .md
+	Extension() string
+	Format(res *Response) ([]byte, error)
+}
+
+// DefaultFormat is used when format is not set with --format or in config.yaml.
+const DefaultFormat = "markdown"
+
+var formatters = map[string]Formatter{
+	"markdown": markdownFormatter{},
+	"json":     jsonFormatter{},
+	"yaml":     yamlFormatter{},
+	"har":      harFormatter{},
+	"http":     httpFormatter{},
+}
+
+var formatAliases = map[string]string{"md": "markdown", "yml": "yaml", "raw": "http"}
+
+// GetFormatters returns formatters of the given format names, duplicate names
+// are written only once.
+func GetFormatters(names []string) ([]Formatter, error) {
+	if len(names) == 0 {
+		names = []string{DefaultFormat}
+	}
+	var selected []Formatter
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if alias, ok := formatAliases[name]; ok {
+			name = alias
+		}
+		formatter, ok := formatters[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown format %s, use one of %s", name, strings.Join(FormatNames(), ", "))
+		}
+		if !seen[name] {
+			seen[name] = true
+			selected = append(selected, formatter)
+		}
+	}
+	return selected, nil
+}
+
+func FormatNames() []string {
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sentBody returns body of the sent request, it is buffered while sending so
+// that it can be read again.
+func sentBody(req *http.Request) []byte {
+	if req == nil || req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+	bodyBytes, _ := io.ReadAll(body)
+	return bodyBytes
+}
+
+// sentPart is a single part of the sent multipart body
+type sentPart struct {
+	Header      http.Header
+	Name        string
+	FileName    string
+	ContentType string
+	Content     []byte
+}
+
+// sentParts parses the sent multipart body, ok is false when the body is not
+// multipart or it can't be parsed.
+func sentParts(header http.Header, body []byte) (parts []sentPart, ok bool) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return nil, false
+	}
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return parts, true
+		}
+		if err != nil {
+			return nil, false
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return nil, false
+		}
+		parts = append(parts, sentPart{
+			Header:      http.Header(part.Header),
+			Name:        part.FormName(),
+			FileName:    part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+			Content:     content,
+		})
+	}
+}
+
+// isBinaryBody is true for the bodies which can't be written as text
+func isBinaryBody(header http.Header, body []byte) bool {
+	return utils.DetectMedia(header, body).Binary
+}
+
+// binaryPlaceholder is written instead of binary content in text formats
+func binaryPlaceholder(size int) string {
+	return fmt.Sprintf("<binary content, %d bytes>", size)
+}
@@ -0,0 +1,301 @@
+package svc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// harFormatter writes HTTP Archive 1.2, every redirect hop is a separate entry
+// (http://www.softwareishard.com/blog/har-12-spec/).
+type harFormatter struct{}
+
+func (harFormatter) Extension() string { return ".har" }
+
+type har struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+type harPostData struct {
+	MimeType string     `json:"mimeType"`
+	Params   []harParam `json:"params"`
+	Text     string     `json:"text"`
+	// Encoding is base64 for binary bodies, the same as in the content of
+	// the response.
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// harParam is a posted form field, file parts of multipart bodies only have
+// the file name and content type.
+type harParam struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	FileName    string `json:"fileName,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+type harContent struct {
+	Size        int    `json:"size"`
+	Compression int    `json:"compression,omitempty"`
+	MimeType    string `json:"mimeType"`
+	Text        string `json:"text,omitempty"`
+	Encoding    string `json:"encoding,omitempty"`
+}
+
+// harTimings are in milliseconds, -1 means the timing is not applicable
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+func (harFormatter) Format(r *Response) ([]byte, error) {
+	doc := har{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "restler", Version: r.Version},
+	}}
+
+	// timing is only known for the whole request, it is set on the final entry
+	for _, hop := range RedirectChain(r.HTTP) {
+		entry := harEntry{
+			StartedDateTime: r.StartedAt.Format(time.RFC3339Nano),
+			Request:         newHarRequest(hop.Request),
+			Response:        newHarResponse(hop, nil, nil, false),
+			Timings:         harTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1},
+			Comment:         "redirect",
+		}
+		doc.Log.Entries = append(doc.Log.Entries, entry)
+	}
+
+	entry := harEntry{
+		StartedDateTime: r.StartedAt.Format(time.RFC3339Nano),
+		Time:            milliseconds(r.Timing.Total),
+		Request:         newHarRequest(r.HTTP.Request),
+		Response:        newHarResponse(r.HTTP, r.RawBody, r.Body, r.Media.Binary),
+		Timings:         newHarTimings(r),
+	}
+	doc.Log.Entries = append(doc.Log.Entries, entry)
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func newHarRequest(req *http.Request) harRequest {
+	harReq := harRequest{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: req.Proto,
+		Cookies:     []harCookie{},
+		Headers:     harHeaders(req.Header),
+		QueryString: harValues(req.URL.Query()),
+		HeadersSize: -1,
+	}
+	if harReq.HTTPVersion == "" {
+		harReq.HTTPVersion = "HTTP/1.1"
+	}
+	for _, cookie := range req.Cookies() {
+		harReq.Cookies = append(harReq.Cookies, harCookie{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	body := sentBody(req)
+	harReq.BodySize = len(body)
+	if len(body) > 0 {
+		harReq.PostData = newHarPostData(req.Header, body)
+	}
+	return harReq
+}
+
+// newHarPostData has params of urlencoded and multipart bodies, content of
+// the files is not included, other binary bodies are encoded in base64.
+func newHarPostData(header http.Header, body []byte) *harPostData {
+	contentType := header.Get("Content-Type")
+	postData := &harPostData{MimeType: contentType, Params: []harParam{}}
+	if parts, ok := sentParts(header, body); ok {
+		for _, part := range parts {
+			param := harParam{Name: part.Name, FileName: part.FileName, ContentType: part.ContentType}
+			if part.FileName == "" {
+				param.Value = string(part.Content)
+			}
+			postData.Params = append(postData.Params, param)
+		}
+		return postData
+	}
+
+	if isBinaryBody(header, body) {
+		postData.Text = base64.StdEncoding.EncodeToString(body)
+		postData.Encoding = "base64"
+		return postData
+	}
+	postData.Text = string(body)
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "application/x-www-form-urlencoded" {
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			for _, value := range harValues(values) {
+				postData.Params = append(postData.Params, harParam{Name: value.Name, Value: value.Value})
+			}
+		}
+	}
+	return postData
+}
+
+func newHarResponse(res *http.Response, rawBody []byte, body []byte, binary bool) harResponse {
+	harRes := harResponse{
+		Status:      res.StatusCode,
+		StatusText:  strings.TrimSpace(strings.TrimPrefix(res.Status, strconv.Itoa(res.StatusCode))),
+		HTTPVersion: res.Proto,
+		Cookies:     []harCookie{},
+		Headers:     harHeaders(res.Header),
+		Content: harContent{
+			Size:     len(body),
+			MimeType: res.Header.Get("Content-Type"),
+		},
+		RedirectURL: res.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(rawBody),
+	}
+	if len(rawBody) != len(body) {
+		harRes.Content.Compression = len(body) - len(rawBody)
+	}
+	if binary {
+		harRes.Content.Text = base64.StdEncoding.EncodeToString(body)
+		harRes.Content.Encoding = "base64"
+	} else {
+		harRes.Content.Text = string(body)
+	}
+	for _, cookie := range res.Cookies() {
+		harCookie := harCookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Path:     cookie.Path,
+			Domain:   cookie.Domain,
+			HTTPOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		}
+		if !cookie.Expires.IsZero() {
+			harCookie.Expires = cookie.Expires.Format(time.RFC3339)
+		}
+		harRes.Cookies = append(harRes.Cookies, harCookie)
+	}
+	return harRes
+}
+
+func newHarTimings(r *Response) harTimings {
+	timing := r.Timing
+	timings := harTimings{
+		Blocked: -1,
+		DNS:     -1,
+		Connect: -1,
+		SSL:     -1,
+		Receive: milliseconds(timing.ContentTransfer),
+	}
+	if !timing.ConnReused {
+		timings.DNS = milliseconds(timing.DNSLookup)
+		// connect time of har includes ssl
+		timings.Connect = milliseconds(timing.TCPConnect + timing.TLSHandshake)
+		if r.HTTP.TLS != nil {
+			timings.SSL = milliseconds(timing.TLSHandshake)
+		}
+	}
+	wait := timing.TimeToFirstByte - timing.DNSLookup - timing.TCPConnect - timing.TLSHandshake
+	if wait > 0 {
+		timings.Wait = milliseconds(wait)
+	}
+	return timings
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	return harValues(url.Values(header))
+}
+
+// harValues returns name value pairs sorted by name so that the file is stable
+func harValues(values url.Values) []harNameValue {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := []harNameValue{}
+	for _, name := range names {
+		for _, value := range values[name] {
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+	return pairs
+}
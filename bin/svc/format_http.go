@@ -0,0 +1,95 @@
+package svc
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+)
+
+// httpFormatter writes HTTP/1.1 transcript of the request and the response,
+// redirect hops are written before the final exchange. Body of the response
+// is written decoded, the headers are kept as they are received.
+type httpFormatter struct{}
+
+func (httpFormatter) Extension() string { return ".http" }
+
+func (httpFormatter) Format(r *Response) ([]byte, error) {
+	var buffer bytes.Buffer
+	for _, hop := range RedirectChain(r.HTTP) {
+		writeHTTPRequest(&buffer, hop.Request)
+		writeHTTPResponseHead(&buffer, hop)
+		buffer.WriteString("\n")
+	}
+
+	writeHTTPRequest(&buffer, r.HTTP.Request)
+	writeHTTPResponseHead(&buffer, r.HTTP)
+	buffer.Write(r.Body)
+	if len(r.Body) > 0 && r.Body[len(r.Body)-1] != '\n' {
+		buffer.WriteString("\n")
+	}
+	return buffer.Bytes(), nil
+}
+
+func writeHTTPRequest(buffer *bytes.Buffer, req *http.Request) {
+	buffer.WriteString(fmt.Sprintf("%s %s HTTP/1.1\r\n", req.Method, req.URL.RequestURI()))
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	buffer.WriteString(fmt.Sprintf("Host: %s\r\n", host))
+	writeHTTPHeader(buffer, req.Header)
+	buffer.WriteString("\r\n")
+	if body := sentBody(req); len(body) > 0 {
+		writeHTTPBody(buffer, req.Header, body)
+		buffer.WriteString("\r\n")
+	}
+	buffer.WriteString("\n")
+}
+
+// writeHTTPBody writes the sent body, binary content including the binary
+// files of multipart bodies is replaced with its size.
+func writeHTTPBody(buffer *bytes.Buffer, header http.Header, body []byte) {
+	if parts, ok := sentParts(header, body); ok {
+		_, params, _ := mime.ParseMediaType(header.Get("Content-Type"))
+		for _, part := range parts {
+			buffer.WriteString("--" + params["boundary"] + "\r\n")
+			writeHTTPHeader(buffer, part.Header)
+			buffer.WriteString("\r\n")
+			if isBinaryBody(part.Header, part.Content) {
+				buffer.WriteString(binaryPlaceholder(len(part.Content)))
+			} else {
+				buffer.Write(part.Content)
+			}
+			buffer.WriteString("\r\n")
+		}
+		buffer.WriteString("--" + params["boundary"] + "--")
+		return
+	}
+	if isBinaryBody(header, body) {
+		buffer.WriteString(binaryPlaceholder(len(body)))
+		return
+	}
+	buffer.Write(body)
+}
+
+func writeHTTPResponseHead(buffer *bytes.Buffer, res *http.Response) {
+	buffer.WriteString(fmt.Sprintf("%s %s\r\n", res.Proto, res.Status))
+	writeHTTPHeader(buffer, res.Header)
+	buffer.WriteString("\r\n")
+}
+
+// writeHTTPHeader writes headers sorted by name so that the file is stable
+func writeHTTPHeader(buffer *bytes.Buffer, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			buffer.WriteString(fmt.Sprintf("%s: %s\r\n", name, value))
+		}
+	}
+}
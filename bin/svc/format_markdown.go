@@ -0,0 +1,151 @@
+package svc
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shrijan00003/restler/core/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// markdownFormatter writes the human readable .res.md report.
+type markdownFormatter struct{}
+
+func (markdownFormatter) Extension() string { return ".md" }
+
+func (markdownFormatter) Format(r *Response) ([]byte, error) {
+	req, res := r.Request, r.HTTP
+
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("# Response For: %s \n", req.Name))
+	buffer.WriteString(fmt.Sprintf("Status Code: %d, Status: %s\n", res.StatusCode, res.Status))
+	if len(r.Assertions) > 0 {
+		writeAssertions(&buffer, r.Assertions)
+	}
+	if len(r.SchemaViolations) > 0 {
+		writeSchemaViolations(&buffer, r.SchemaViolations)
+	}
+	buffer.WriteString("\n\n")
+	buffer.WriteString("## Request Time\n")
+	buffer.WriteString(r.Timing.Total.String())
+	buffer.WriteString("\n\n| Phase | Duration |\n")
+	buffer.WriteString("| --- | --- |\n")
+	buffer.WriteString(fmt.Sprintf("| DNS Lookup | %s |\n", r.Timing.DNSLookup))
+	buffer.WriteString(fmt.Sprintf("| TCP Connect | %s |\n", r.Timing.TCPConnect))
+	buffer.WriteString(fmt.Sprintf("| TLS Handshake | %s |\n", r.Timing.TLSHandshake))
+	buffer.WriteString(fmt.Sprintf("| Time To First Byte | %s |\n", r.Timing.TimeToFirstByte))
+	buffer.WriteString(fmt.Sprintf("| Content Transfer | %s |\n", r.Timing.ContentTransfer))
+	buffer.WriteString(fmt.Sprintf("| Total | %s |", r.Timing.Total))
+
+	if len(r.Attempts) > 1 {
+		buffer.WriteString("\n\n## Attempts\n")
+		buffer.WriteString("| Attempt | Status Code | Error | Duration | Wait |\n")
+		buffer.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, attempt := range r.Attempts {
+			buffer.WriteString(fmt.Sprintf("| %d | %d | %s | %s | %s |\n", attempt.Number, attempt.StatusCode, attempt.Error, attempt.Duration, attempt.Wait))
+		}
+	}
+	if res.TLS != nil {
+		writeTLSInfo(&buffer, res.TLS)
+	}
+
+	if chain := RedirectChain(res); len(chain) > 0 {
+		buffer.WriteString("\n\n## Redirects\n")
+		buffer.WriteString("| # | Status Code | URL | Location | Set-Cookie |\n")
+		buffer.WriteString("| --- | --- | --- | --- | --- |\n")
+		for i, hop := range chain {
+			buffer.WriteString(fmt.Sprintf("| %d | %d | %s | %s | %s |\n", i+1, hop.StatusCode, hop.Request.URL, hop.Header.Get("Location"), strings.Join(hop.Header.Values("Set-Cookie"), "<br>")))
+		}
+	}
+	buffer.WriteString("\n\n## Response Header: \n")
+
+	for key, value := range res.Header {
+		buffer.WriteString(fmt.Sprintf("%s: %s\n", key, value))
+	}
+	buffer.WriteString("\n\n")
+	if encodings := utils.ContentEncodings(res.Header); len(encodings) > 0 {
+		buffer.WriteString("## Response Size: \n")
+		buffer.WriteString(fmt.Sprintf("Encoding: %s, Compressed: %d bytes, Decompressed: %d bytes\n\n", strings.Join(encodings, ", "), len(r.RawBody), len(r.Body)))
+	}
+	buffer.WriteString("## Response Body: \n")
+	if r.BodyFile != "" {
+		buffer.WriteString(fmt.Sprintf("Binary body (%s, %d bytes) saved to [%s](./%s)", r.Media.MediaType, len(r.Body), r.BodyFile, r.BodyFile))
+	} else {
+		buffer.WriteString(fmt.Sprintf("```%s\n", utils.CodeFence(r.Media.MediaType)))
+		buffer.Write(r.Display)
+		buffer.WriteString("\n```")
+	}
+	buffer.WriteString("\n\n")
+	buffer.WriteString("## Original Request \n")
+	buffer.WriteString(fmt.Sprintf("Method: %s, URL: %s\n", res.Request.Method, res.Request.URL))
+	// ignoring errors here
+	requestBytes, _ := getRequestBytes(req)
+	buffer.WriteString("\n```yaml\n")
+	buffer.Write(requestBytes)
+	buffer.WriteString("\n```")
+
+	// multipart parts are summarized instead of writing the raw (binary) content
+	if len(req.Parts) > 0 {
+		buffer.WriteString("\n\n## Request Parts \n")
+		buffer.WriteString("| Name | Filename | Content-Type | Size |\n")
+		buffer.WriteString("| --- | --- | --- | --- |\n")
+		for _, part := range req.Parts {
+			buffer.WriteString(fmt.Sprintf("| %s | %s | %s | %d bytes |\n", part.Name, part.Filename, part.ContentType, part.Size))
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+func getRequestBytes(req *Request) ([]byte, error) {
+	if req.Body != nil {
+		return yaml.Marshal(RedactRequest(req))
+	}
+	return nil, nil
+}
+
+func writeAssertions(buffer *bytes.Buffer, results AssertResults) {
+	buffer.WriteString("\n\n## Assertions\n")
+	buffer.WriteString(fmt.Sprintf("%d passed, %d failed\n\n", len(results)-results.Failed(), results.Failed()))
+	buffer.WriteString("| Result | Assertion | Message |\n")
+	buffer.WriteString("| --- | --- | --- |\n")
+	for _, result := range results {
+		buffer.WriteString(fmt.Sprintf("| %s | %s | %s |\n", result.Result(), markdownCell(result.Name), markdownCell(result.Message)))
+	}
+}
+
+func writeSchemaViolations(buffer *bytes.Buffer, violations []SchemaViolation) {
+	buffer.WriteString("\n\n## Schema Violations\n")
+	buffer.WriteString("| Pointer | Schema Keyword | Message |\n")
+	buffer.WriteString("| --- | --- | --- |\n")
+	for _, violation := range violations {
+		buffer.WriteString(fmt.Sprintf("| `%s` | `%s` | %s |\n", violation.DisplayPointer(), violation.Keyword, markdownCell(violation.Message)))
+	}
+}
+
+// markdownCell escapes | so that the value doesn't break the table
+func markdownCell(value string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(value, "|", "\\|"), "\n", " ")
+}
+
+func writeTLSInfo(buffer *bytes.Buffer, state *tls.ConnectionState) {
+	buffer.WriteString("\n\n## TLS\n")
+	buffer.WriteString(fmt.Sprintf("Version: %s\n", tls.VersionName(state.Version)))
+	buffer.WriteString(fmt.Sprintf("Cipher Suite: %s\n", tls.CipherSuiteName(state.CipherSuite)))
+	if state.NegotiatedProtocol != "" {
+		buffer.WriteString(fmt.Sprintf("Protocol: %s\n", state.NegotiatedProtocol))
+	}
+	buffer.WriteString(fmt.Sprintf("Server Name: %s\n", state.ServerName))
+
+	buffer.WriteString("\n### Certificate Chain\n")
+	buffer.WriteString("| # | Subject | Issuer | Not Before | Not After | DNS Names |\n")
+	buffer.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for i, cert := range state.PeerCertificates {
+		buffer.WriteString(fmt.Sprintf("| %d | %s | %s | %s | %s | %s |\n",
+			i, cert.Subject, cert.Issuer,
+			cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339),
+			strings.Join(cert.DNSNames, ", ")))
+	}
+}
@@ -0,0 +1,43 @@
+package svc
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/shrijan00003/restler/core/app"
+)
+
+func TestMarkdownRedactsSecrets(t *testing.T) {
+	req := &Request{
+		Name:   "Create Post",
+		URL:    "https://api.example.com/posts",
+		Method: http.MethodPost,
+		Body:   map[string]interface{}{"title": "restler"},
+		TLS:    &app.TLS{PKCS12: "certs/client.p12", PKCS12Password: "hunter2"},
+	}
+	res := &Response{
+		Request: req,
+		HTTP: &http.Response{
+			StatusCode: http.StatusCreated,
+			Status:     "201 Created",
+			Header:     http.Header{},
+			Request:    &http.Request{Method: req.Method, URL: &url.URL{Scheme: "https", Host: "api.example.com", Path: "/posts"}},
+		},
+	}
+
+	output, err := markdownFormatter{}.Format(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(output), "hunter2") {
+		t.Errorf("response file has the PKCS#12 password:\n%s", output)
+	}
+	if !strings.Contains(string(output), "PKCS12Password: '[REDACTED]'") {
+		t.Errorf("response file should show the password is redacted:\n%s", output)
+	}
+	if req.TLS.PKCS12Password != "hunter2" {
+		t.Errorf("request is changed, password = %q", req.TLS.PKCS12Password)
+	}
+}
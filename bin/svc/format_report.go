@@ -0,0 +1,224 @@
+package svc
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/shrijan00003/restler/core/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// report is the machine readable response written by json and yaml formats,
+// durations are in milliseconds.
+type report struct {
+	Name      string             `json:"name" yaml:"name"`
+	Request   reportRequest      `json:"request" yaml:"request"`
+	Response  reportResponse     `json:"response" yaml:"response"`
+	Timing    map[string]float64 `json:"timing" yaml:"timing"`
+	Attempts  []reportAttempt    `json:"attempts,omitempty" yaml:"attempts,omitempty"`
+	Redirects []reportRedirect   `json:"redirects,omitempty" yaml:"redirects,omitempty"`
+	TLS       *reportTLS         `json:"tls,omitempty" yaml:"tls,omitempty"`
+
+	Assertions       AssertResults     `json:"assertions,omitempty" yaml:"assertions,omitempty"`
+	SchemaViolations []SchemaViolation `json:"schemaViolations,omitempty" yaml:"schemaViolations,omitempty"`
+}
+
+type reportRequest struct {
+	Method  string      `json:"method" yaml:"method"`
+	URL     string      `json:"url" yaml:"url"`
+	Headers http.Header `json:"headers" yaml:"headers"`
+	Body    interface{} `json:"body,omitempty" yaml:"body,omitempty"`
+	Parts   []Part      `json:"parts,omitempty" yaml:"parts,omitempty"`
+}
+
+type reportResponse struct {
+	StatusCode  int         `json:"statusCode" yaml:"statusCode"`
+	Status      string      `json:"status" yaml:"status"`
+	Proto       string      `json:"proto" yaml:"proto"`
+	Headers     http.Header `json:"headers" yaml:"headers"`
+	MediaType   string      `json:"mediaType" yaml:"mediaType"`
+	Charset     string      `json:"charset,omitempty" yaml:"charset,omitempty"`
+	Encodings   []string    `json:"encodings,omitempty" yaml:"encodings,omitempty"`
+	EncodedSize int         `json:"encodedSize" yaml:"encodedSize"`
+	Size        int         `json:"size" yaml:"size"`
+	Body        interface{} `json:"body,omitempty" yaml:"body,omitempty"`
+	BodyFile    string      `json:"bodyFile,omitempty" yaml:"bodyFile,omitempty"`
+}
+
+type reportAttempt struct {
+	Number     int     `json:"number" yaml:"number"`
+	StatusCode int     `json:"statusCode,omitempty" yaml:"statusCode,omitempty"`
+	Error      string  `json:"error,omitempty" yaml:"error,omitempty"`
+	Duration   float64 `json:"duration" yaml:"duration"`
+	Wait       float64 `json:"wait" yaml:"wait"`
+}
+
+type reportRedirect struct {
+	StatusCode int      `json:"statusCode" yaml:"statusCode"`
+	URL        string   `json:"url" yaml:"url"`
+	Location   string   `json:"location" yaml:"location"`
+	SetCookie  []string `json:"setCookie,omitempty" yaml:"setCookie,omitempty"`
+}
+
+type reportTLS struct {
+	Version      string              `json:"version" yaml:"version"`
+	CipherSuite  string              `json:"cipherSuite" yaml:"cipherSuite"`
+	Protocol     string              `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+	ServerName   string              `json:"serverName" yaml:"serverName"`
+	Certificates []reportCertificate `json:"certificates" yaml:"certificates"`
+}
+
+type reportCertificate struct {
+	Subject   string   `json:"subject" yaml:"subject"`
+	Issuer    string   `json:"issuer" yaml:"issuer"`
+	NotBefore string   `json:"notBefore" yaml:"notBefore"`
+	NotAfter  string   `json:"notAfter" yaml:"notAfter"`
+	DNSNames  []string `json:"dnsNames,omitempty" yaml:"dnsNames,omitempty"`
+}
+
+// +++++++++++++++++++++++++++++++++++++++++++++
+// json
+// +++++++++++++++++++++++++++++++++++++++++++++
+type jsonFormatter struct{}
+
+func (jsonFormatter) Extension() string { return ".json" }
+
+func (jsonFormatter) Format(r *Response) ([]byte, error) {
+	var body interface{}
+	if r.BodyFile == "" && len(r.Body) > 0 {
+		body = string(r.Display)
+		// json body is embedded as it is so that order of the keys is kept
+		if json.Valid(r.Body) && !utils.IsJSONLinesMedia(r.Media.MediaType) {
+			body = json.RawMessage(r.Body)
+		}
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(newReport(r, body)); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// +++++++++++++++++++++++++++++++++++++++++++++
+// yaml
+// +++++++++++++++++++++++++++++++++++++++++++++
+type yamlFormatter struct{}
+
+func (yamlFormatter) Extension() string { return ".yaml" }
+
+func (yamlFormatter) Format(r *Response) ([]byte, error) {
+	var body interface{}
+	if r.BodyFile == "" && len(r.Body) > 0 {
+		body = string(r.Display)
+		if json.Valid(r.Body) && !utils.IsJSONLinesMedia(r.Media.MediaType) {
+			// json is valid yaml, parsing it as yaml node keeps order of the keys
+			var node yaml.Node
+			if err := yaml.Unmarshal(r.Body, &node); err == nil && len(node.Content) > 0 {
+				blockStyle(node.Content[0])
+				body = node.Content[0]
+			}
+		}
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(newReport(r, body)); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), encoder.Close()
+}
+
+// blockStyle converts json flow style to yaml block style.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+func newReport(r *Response, body interface{}) report {
+	req, res := r.Request, r.HTTP
+	rep := report{
+		Name: req.Name,
+		Request: reportRequest{
+			Method:  res.Request.Method,
+			URL:     res.Request.URL.String(),
+			Headers: res.Request.Header,
+			Body:    req.Body,
+			Parts:   req.Parts,
+		},
+		Response: reportResponse{
+			StatusCode:  res.StatusCode,
+			Status:      res.Status,
+			Proto:       res.Proto,
+			Headers:     res.Header,
+			MediaType:   r.Media.MediaType,
+			Charset:     r.Media.Charset,
+			Encodings:   utils.ContentEncodings(res.Header),
+			EncodedSize: len(r.RawBody),
+			Size:        len(r.Body),
+			Body:        body,
+			BodyFile:    r.BodyFile,
+		},
+		Timing:           map[string]float64{},
+		Assertions:       r.Assertions,
+		SchemaViolations: r.SchemaViolations,
+	}
+	for name, duration := range r.Timing.Values() {
+		rep.Timing[name] = milliseconds(duration)
+	}
+	if len(r.Attempts) > 1 {
+		for _, attempt := range r.Attempts {
+			rep.Attempts = append(rep.Attempts, reportAttempt{
+				Number:     attempt.Number,
+				StatusCode: attempt.StatusCode,
+				Error:      attempt.Error,
+				Duration:   milliseconds(attempt.Duration),
+				Wait:       milliseconds(attempt.Wait),
+			})
+		}
+	}
+	for _, hop := range RedirectChain(res) {
+		rep.Redirects = append(rep.Redirects, reportRedirect{
+			StatusCode: hop.StatusCode,
+			URL:        hop.Request.URL.String(),
+			Location:   hop.Header.Get("Location"),
+			SetCookie:  hop.Header.Values("Set-Cookie"),
+		})
+	}
+	if res.TLS != nil {
+		rep.TLS = newReportTLS(res.TLS)
+	}
+	return rep
+}
+
+func newReportTLS(state *tls.ConnectionState) *reportTLS {
+	info := &reportTLS{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		Protocol:    state.NegotiatedProtocol,
+		ServerName:  state.ServerName,
+	}
+	for _, cert := range state.PeerCertificates {
+		info.Certificates = append(info.Certificates, reportCertificate{
+			Subject:   cert.Subject.String(),
+			Issuer:    cert.Issuer.String(),
+			NotBefore: cert.NotBefore.Format(time.RFC3339),
+			NotAfter:  cert.NotAfter.Format(time.RFC3339),
+			DNSNames:  cert.DNSNames,
+		})
+	}
+	return info
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,161 @@
+package svc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shrijan00003/restler/core/app"
+)
+
+// binaryContent is not valid utf-8, so it is detected as binary
+var binaryContent = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\xff\xfe")
+
+// sendRequest sends the request to a test server and returns the response
+// ready for the formatters.
+func sendRequest(t *testing.T, req *Request) *Response {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1}`))
+	}))
+	t.Cleanup(server.Close)
+
+	req.URL = server.URL + "/upload"
+	req.Method = http.MethodPost
+	res, err := ProcessRequest(req, &app.App{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Response{Request: req, HTTP: res, RawBody: body, Body: body, Display: body}
+}
+
+func multipartRequest(t *testing.T) *Request {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "avatar.png"), binaryContent, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &Request{
+		Dir:     dir,
+		Headers: map[string]string{"Content-Type": "multipart/form-data"},
+		Body: map[string]interface{}{
+			"name":   "John",
+			"avatar": map[string]interface{}{"File": "avatar.png"},
+		},
+	}
+}
+
+func TestHarMultipartRequest(t *testing.T) {
+	output, err := harFormatter{}.Format(sendRequest(t, multipartRequest(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc har
+	if err := json.Unmarshal(output, &doc); err != nil {
+		t.Fatalf("invalid har: %v", err)
+	}
+	postData := doc.Log.Entries[0].Request.PostData
+	if postData == nil || !strings.HasPrefix(postData.MimeType, "multipart/form-data; boundary=") {
+		t.Fatalf("postData = %+v, want multipart mime type", postData)
+	}
+	want := []harParam{
+		{Name: "avatar", FileName: "avatar.png", ContentType: "image/png"},
+		{Name: "name", Value: "John"},
+	}
+	if len(postData.Params) != len(want) || postData.Params[0] != want[0] || postData.Params[1] != want[1] {
+		t.Errorf("params = %+v, want %+v", postData.Params, want)
+	}
+	if postData.Text != "" || strings.Contains(string(output), "IHDR") {
+		t.Errorf("har should not have the raw multipart body:\n%s", output)
+	}
+}
+
+func TestHarBinaryRequest(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "avatar.png"), binaryContent, 0644); err != nil {
+		t.Fatal(err)
+	}
+	req := &Request{Dir: dir, BodyMode: BodyModeBinary, Body: "avatar.png", Headers: map[string]string{}}
+	output, err := harFormatter{}.Format(sendRequest(t, req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc har
+	if err := json.Unmarshal(output, &doc); err != nil {
+		t.Fatalf("invalid har: %v", err)
+	}
+	postData := doc.Log.Entries[0].Request.PostData
+	if postData == nil || postData.Encoding != "base64" || postData.Text != base64.StdEncoding.EncodeToString(binaryContent) {
+		t.Errorf("postData = %+v, want base64 encoded body", postData)
+	}
+}
+
+func TestHarFormRequest(t *testing.T) {
+	req := &Request{
+		Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		Body:    map[string]interface{}{"name": "John Doe", "tags": []interface{}{"a", "b"}},
+	}
+	output, err := harFormatter{}.Format(sendRequest(t, req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc har
+	if err := json.Unmarshal(output, &doc); err != nil {
+		t.Fatalf("invalid har: %v", err)
+	}
+	postData := doc.Log.Entries[0].Request.PostData
+	if postData == nil || postData.Text != "name=John+Doe&tags=a&tags=b" || len(postData.Params) != 3 || postData.Encoding != "" {
+		t.Errorf("postData = %+v, want text and params of the form", postData)
+	}
+}
+
+func TestHTTPMultipartRequest(t *testing.T) {
+	res := sendRequest(t, multipartRequest(t))
+	output, err := httpFormatter{}.Format(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transcript := string(output)
+	if strings.Contains(transcript, "IHDR") {
+		t.Errorf("transcript should not have the binary file:\n%s", transcript)
+	}
+	for _, want := range []string{
+		"POST /upload HTTP/1.1\r\n",
+		`Content-Disposition: form-data; name="avatar"; filename="avatar.png"` + "\r\n",
+		"Content-Type: image/png\r\n\r\n" + binaryPlaceholder(len(binaryContent)) + "\r\n",
+		`Content-Disposition: form-data; name="name"` + "\r\n\r\nJohn\r\n",
+		`{"id":1}`,
+	} {
+		if !strings.Contains(transcript, want) {
+			t.Errorf("transcript should have %q:\n%s", want, transcript)
+		}
+	}
+}
+
+func TestHTTPBinaryRequest(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "avatar.png"), binaryContent, 0644); err != nil {
+		t.Fatal(err)
+	}
+	req := &Request{Dir: dir, BodyMode: BodyModeBinary, Body: "avatar.png", Headers: map[string]string{}}
+	output, err := httpFormatter{}.Format(sendRequest(t, req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(output), "\r\n\r\n"+binaryPlaceholder(len(binaryContent))+"\r\n") {
+		t.Errorf("transcript should have the size of the binary body:\n%s", output)
+	}
+}
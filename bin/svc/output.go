@@ -0,0 +1,65 @@
+package svc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Print modes of the response written to stdout with --print flag.
+const (
+	PrintBody    = "body"    // response body only
+	PrintHeaders = "headers" // status line, headers and the body like curl -i
+	PrintReport  = "report"  // full report of the first response format
+)
+
+// PrintResponse writes the response to w as per the print mode, text body is
+// written as displayed in the report and binary body is written as it is.
+func PrintResponse(w io.Writer, r *Response, mode string, report Formatter) error {
+	var buffer bytes.Buffer
+	switch mode {
+	case PrintBody:
+		writePrintBody(&buffer, r)
+	case PrintHeaders:
+		writeHTTPResponseHead(&buffer, r.HTTP)
+		writePrintBody(&buffer, r)
+	case PrintReport:
+		reportBytes, err := report.Format(r)
+		if err != nil {
+			return err
+		}
+		buffer.Write(reportBytes)
+	default:
+		return fmt.Errorf("unknown print mode %s, use one of %s, %s, %s", mode, PrintBody, PrintHeaders, PrintReport)
+	}
+	_, err := w.Write(buffer.Bytes())
+	return err
+}
+
+func writePrintBody(buffer *bytes.Buffer, r *Response) {
+	if r.Media.Binary {
+		buffer.Write(r.Body)
+		return
+	}
+	buffer.Write(r.Display)
+	// text output should end with new line for the terminal
+	if len(r.Display) > 0 && r.Display[len(r.Display)-1] != '\n' {
+		buffer.WriteString("\n")
+	}
+}
+
+// StatusExitCode returns exit code for the status class of the response when
+// --status-exit flag is used, 2xx is success.
+//
+//	1xx, 2xx: 0
+//	3xx:      3
+//	4xx:      4
+//	5xx:      5
+func StatusExitCode(statusCode int) int {
+	switch {
+	case statusCode >= 300 && statusCode < 600:
+		return statusCode / 100
+	default:
+		return 0
+	}
+}
@@ -1,16 +1,100 @@
 package svc
 
-import "os"
-
-// TODO: Add support for NO_PROXY
-func GetProxyURL() string {
-	gProxyUrl := os.Getenv("HTTPS_PROXY")
-	if gProxyUrl == "" {
-		gProxyUrl = os.Getenv("HTTP_PROXY")
-		if gProxyUrl == "" {
-			gProxyUrl = ""
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/shrijan00003/restler/core/app"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// GetProxyURL returns the proxy for the target url, nil means no proxy.
+// Per host rules from config.yaml are checked first, then HTTPS_PROXY or
+// HTTP_PROXY is picked from env based on the scheme of the target, unless the
+// host is excluded with NO_PROXY. ALL_PROXY is used when neither is set.
+func GetProxyURL(target *url.URL, rules []app.ProxyRule) (*url.URL, error) {
+	for _, rule := range rules {
+		if !matchProxyHost(rule.Host, target) {
+			continue
+		}
+		if rule.URL == "" || strings.EqualFold(rule.URL, "DIRECT") {
+			return nil, nil
+		}
+		proxyURL, err := url.Parse(rule.URL)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing proxy url for host %s, error: %s", rule.Host, err)
 		}
+		return proxyURL, nil
+	}
+
+	config := httpproxy.FromEnvironment()
+	allProxy := getEnvAny("ALL_PROXY", "all_proxy")
+	if config.HTTPProxy == "" {
+		config.HTTPProxy = allProxy
+	}
+	if config.HTTPSProxy == "" {
+		config.HTTPSProxy = allProxy
+	}
+	return config.ProxyFunc()(target)
+}
+
+// matchProxyHost matches host of the target with the pattern of proxy rule,
+// it supports the same format as NO_PROXY.
+func matchProxyHost(pattern string, target *url.URL) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "" {
+		return false
+	}
+	if pattern == "*" {
+		return true
 	}
 
-	return gProxyUrl
+	host := strings.ToLower(target.Hostname())
+	port := target.Port()
+	if port == "" {
+		port = defaultPort(target.Scheme)
+	}
+
+	if _, network, err := net.ParseCIDR(pattern); err == nil {
+		ip := net.ParseIP(host)
+		return ip != nil && network.Contains(ip)
+	}
+
+	// pattern with port only matches the same port
+	if patternHost, patternPort, err := net.SplitHostPort(pattern); err == nil {
+		if patternPort != port {
+			return false
+		}
+		pattern = patternHost
+	}
+
+	if strings.HasPrefix(pattern, "*.") {
+		pattern = pattern[1:]
+	}
+	if strings.HasPrefix(pattern, ".") {
+		return strings.HasSuffix(host, pattern) || host == pattern[1:]
+	}
+	return host == pattern
+}
+
+func defaultPort(scheme string) string {
+	switch scheme {
+	case "https":
+		return "443"
+	case "http":
+		return "80"
+	}
+	return ""
+}
+
+func getEnvAny(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
 }
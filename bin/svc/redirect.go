@@ -0,0 +1,57 @@
+package svc
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+)
+
+const defaultMaxRedirects = 10
+
+// Redirect policy of the request, redirects are followed up to 10 hops by
+// default.
+type Redirect struct {
+	// Follow is true by default, false returns the first redirect response.
+	Follow *bool `yaml:"Follow,omitempty"`
+	// Max hops to follow, last redirect response is returned when it exceeds.
+	Max int `yaml:"Max,omitempty"`
+	// KeepAuth sends Authorization header to the other hosts while following
+	// redirects, by default it is removed when host changes.
+	KeepAuth bool `yaml:"KeepAuth,omitempty"`
+}
+
+func checkRedirect(redirect *Redirect) func(*http.Request, []*http.Request) error {
+	if redirect == nil {
+		redirect = &Redirect{}
+	}
+	max := redirect.Max
+	if max <= 0 {
+		max = defaultMaxRedirects
+	}
+
+	return func(req *http.Request, via []*http.Request) error {
+		if redirect.Follow != nil && !*redirect.Follow {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > max {
+			fmt.Fprintf(os.Stderr, "[restler warning]: stopped after %d redirects\n", max)
+			return http.ErrUseLastResponse
+		}
+		if redirect.KeepAuth && req.Header.Get("Authorization") == "" {
+			if auth := via[0].Header.Get("Authorization"); auth != "" {
+				req.Header.Set("Authorization", auth)
+			}
+		}
+		return nil
+	}
+}
+
+// RedirectChain returns the redirect responses in the order they are
+// followed, final response is not included.
+func RedirectChain(res *http.Response) []*http.Response {
+	var chain []*http.Response
+	for r := res.Request.Response; r != nil; r = r.Request.Response {
+		chain = append([]*http.Response{r}, chain...)
+	}
+	return chain
+}
@@ -0,0 +1,123 @@
+package svc
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/shrijan00003/restler/core/app"
+)
+
+// redirectServer redirects /hop/n to /hop/n-1 until /hop/0, /other redirects
+// to the target url.
+func redirectServer(target string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/other" {
+			http.Redirect(w, r, target, http.StatusFound)
+			return
+		}
+		var hop int
+		fmt.Sscanf(r.URL.Path, "/hop/%d", &hop)
+		if hop > 0 {
+			http.Redirect(w, r, "/hop/"+strconv.Itoa(hop-1), http.StatusMovedPermanently)
+			return
+		}
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+}
+
+func TestRedirectPolicy(t *testing.T) {
+	server := redirectServer("")
+	defer server.Close()
+
+	disabled := false
+	tests := []struct {
+		name       string
+		path       string
+		redirect   *Redirect
+		wantStatus int
+		wantChain  int
+	}{
+		{"followed by default", "/hop/3", nil, http.StatusOK, 3},
+		{"not followed", "/hop/3", &Redirect{Follow: &disabled}, http.StatusMovedPermanently, 0},
+		{"max hops", "/hop/5", &Redirect{Max: 2}, http.StatusMovedPermanently, 2},
+		{"within max", "/hop/2", &Redirect{Max: 2}, http.StatusOK, 2},
+		{"default max", "/hop/12", nil, http.StatusMovedPermanently, defaultMaxRedirects},
+	}
+	for _, tt := range tests {
+		req := &Request{URL: server.URL + tt.path, Method: http.MethodGet, Redirect: tt.redirect}
+		res, err := ProcessRequest(req, &app.App{})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, res.StatusCode, tt.wantStatus)
+		}
+		if chain := RedirectChain(res); len(chain) != tt.wantChain {
+			t.Errorf("%s: redirect chain has %d responses, want %d", tt.name, len(chain), tt.wantChain)
+		}
+	}
+}
+
+func TestRedirectChainOrder(t *testing.T) {
+	server := redirectServer("")
+	defer server.Close()
+
+	res, err := ProcessRequest(&Request{URL: server.URL + "/hop/2", Method: http.MethodGet}, &app.App{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	chain := RedirectChain(res)
+	if len(chain) != 2 || chain[0].Request.URL.Path != "/hop/2" || chain[1].Request.URL.Path != "/hop/1" {
+		t.Errorf("redirect chain should start with the first request")
+	}
+}
+
+func TestRedirectKeepAuth(t *testing.T) {
+	target := redirectServer("")
+	defer target.Close()
+	// both servers listen on 127.0.0.1, localhost makes it a different host
+	origin := redirectServer(strings.Replace(target.URL, "127.0.0.1", "localhost", 1) + "/hop/0")
+	defer origin.Close()
+
+	tests := []struct {
+		name     string
+		redirect *Redirect
+		want     string
+	}{
+		{"removed for other host", nil, ""},
+		{"kept", &Redirect{KeepAuth: true}, "Bearer secret"},
+	}
+	for _, tt := range tests {
+		req := &Request{
+			URL:      origin.URL + "/other",
+			Method:   http.MethodGet,
+			Headers:  map[string]string{"Authorization": "Bearer secret"},
+			Redirect: tt.redirect,
+		}
+		res, err := ProcessRequest(req, &app.App{})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		body := readAll(t, res)
+		if body != tt.want {
+			t.Errorf("%s: Authorization = %q, want %q", tt.name, body, tt.want)
+		}
+	}
+}
+
+func readAll(t *testing.T, res *http.Response) string {
+	t.Helper()
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
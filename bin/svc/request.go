@@ -1,17 +1,15 @@
 package svc
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
-	"reflect"
+	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/shrijan00003/restler/core/app"
+	"github.com/shrijan00003/restler/core/utils"
 	"gopkg.in/yaml.v3"
 )
 
@@ -22,72 +20,138 @@ type Request struct {
 	Headers map[string]string `yaml:"Headers"`
 	Body    interface{}       `yaml:"Body"`
 	After   *After            `yaml:"After"`
+	Assert  *Assert           `yaml:"Assert,omitempty"`
+	// Schema is the JSON Schema of the response body.
+	Schema  *Schema           `yaml:"Schema,omitempty"`
 	Params  map[string]string `yaml:"Params"`
+	Timeout *app.Timeout      `yaml:"Timeout,omitempty"`
+	Retry   *app.Retry        `yaml:"Retry,omitempty"`
+	TLS     *app.TLS          `yaml:"TLS,omitempty"`
+	Proxy   *Proxy            `yaml:"Proxy,omitempty"`
+
+	// Redirect policy of the request, redirects are followed by default.
+	Redirect *Redirect `yaml:"Redirect,omitempty"`
+
+	// BodyMode is one of json, form, multipart, raw, file or binary, it is
+	// detected from Content-Type header when it is not set.
+	BodyMode string `yaml:"BodyMode,omitempty"`
+
+	// Tags select the requests of restler test eg. restler test --tag smoke
+	Tags []string `yaml:"Tags,omitempty"`
+
+	// Dir is the directory of the request file, files referenced from the
+	// request (eg. multipart file parts) are resolved relative to it.
+	Dir string `yaml:"-"`
+	// Parts is filled while building multipart/form-data body.
+	Parts []Part `yaml:"-"`
+}
+
+// Proxy of the request, when it is not set proxy is selected from config.yaml
+// and env, see GetProxyURL.
+type Proxy struct {
+	// Enable is true by default, false sends the request without any proxy.
+	Enable *bool  `yaml:"Enable,omitempty"`
+	URL    string `yaml:"URL,omitempty"`
 }
 
 type After struct {
-	Env map[string]string `yaml:"Env"`
+	Env map[string]*Extractor `yaml:"Env"`
 }
 
 func ParseRequest(reqPath string) (*Request, error) {
 	rawReq, err := os.ReadFile(reqPath)
 	if err != nil {
-		return nil, err
+		return nil, NewError(ErrorKindFile, err)
 	}
 
-	replaced := os.ExpandEnv(string(rawReq))
-	req := &Request{}
-
-	err = yaml.Unmarshal([]byte(replaced), req)
+	// templates are expanded after parsing the yaml, so that values with $
+	// or {{ }} from env can't break the structure of the request
+	var root yaml.Node
+	err = yaml.Unmarshal(rawReq, &root)
+	if err != nil {
+		return nil, &Error{Kind: ErrorKindParse, Path: reqPath, Err: err}
+	}
+	if len(root.Content) == 0 {
+		return nil, &Error{Kind: ErrorKindValidation, Path: reqPath, Err: fmt.Errorf("request file is empty")}
+	}
+	err = expandRequestNode(root.Content[0])
+	if err != nil {
+		return nil, &Error{Kind: ErrorKindOf(err), Path: reqPath, Err: err}
+	}
+	// validated after expanding templates, so that final values are checked
+	err = validateRequest(reqPath, root.Content[0])
 	if err != nil {
 		return nil, err
 	}
 
+	req := &Request{Dir: filepath.Dir(reqPath)}
+	err = root.Decode(req)
+	if err != nil {
+		return nil, &Error{Kind: ErrorKindParse, Path: reqPath, Err: err}
+	}
+	migrateProxyHeaders(req)
+
 	return req, nil
 }
 
-func ProcessRequest(req *Request, app *app.App) (*http.Response, error) {
-	var proxyURL *url.URL = nil
-	var transport *http.Transport = nil
-	var client *http.Client = nil
-
-	sProxyEnable := req.Headers["R-Proxy-Enable"]
-	if sProxyEnable == "" {
-		sProxyEnable = "Y"
+// expandRequestNode expands templates on every section of the request except
+// After, which only has the paths of the response values, and the inline
+// Schema, whose $ref, $defs and $id are part of JSON Schema.
+func expandRequestNode(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return Errorf(ErrorKindParse, "request should be a yaml map")
 	}
-
-	if sProxyEnable == "N" {
-		client = &http.Client{}
-	} else {
-		sProxyUrl := req.Headers["R-Proxy-Url"]
-
-		if sProxyUrl == "" {
-			sProxyUrl = app.ProxyUrl
+	section := &yaml.Node{Kind: yaml.MappingNode}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value == "After" || key.Value == "Schema" && value.Kind != yaml.ScalarNode {
+			continue
 		}
+		section.Content = append(section.Content, node.Content[i], node.Content[i+1])
+	}
+	return NewError(ErrorKindTemplate, expandNode(section))
+}
 
-		if sProxyUrl != "" {
-			var err error
-			proxyURL, err = url.Parse(sProxyUrl)
-			if err != nil {
-				return nil, fmt.Errorf("error parsing proxy url, error: %s", err)
+// migrateProxyHeaders moves deprecated R-Proxy-Enable and R-Proxy-Url headers
+// to Proxy section of the request, Proxy section has higher precedence.
+func migrateProxyHeaders(req *Request) {
+	for key, value := range req.Headers {
+		var name string
+		switch http.CanonicalHeaderKey(key) {
+		case "R-Proxy-Enable":
+			name = "Proxy.Enable"
+			if req.Proxy == nil {
+				req.Proxy = &Proxy{}
 			}
-		}
-
-		if proxyURL != nil {
-			transport = &http.Transport{
-				Proxy: http.ProxyURL(proxyURL),
+			if req.Proxy.Enable == nil {
+				enable := value != "N"
+				req.Proxy.Enable = &enable
+			}
+		case "R-Proxy-Url":
+			name = "Proxy.URL"
+			if req.Proxy == nil {
+				req.Proxy = &Proxy{}
 			}
-			client = &http.Client{
-				Transport: transport,
+			if req.Proxy.URL == "" {
+				req.Proxy.URL = value
 			}
-		} else {
-			client = &http.Client{}
+		default:
+			continue
 		}
+		fmt.Fprintf(os.Stderr, "[restler warning]: %s header is deprecated, use %s in the request instead\n", key, name)
+		delete(req.Headers, key)
+	}
+}
+
+func ProcessRequest(req *Request, app *app.App) (*http.Response, error) {
+	client, err := newClient(req, app)
+	if err != nil {
+		return nil, err
 	}
 
 	u, e := url.Parse(req.URL)
 	if e != nil {
-		return nil, fmt.Errorf("Not a valid url, error is %w", e)
+		return nil, Errorf(ErrorKindValidation, "Not a valid url, error is %w", e)
 	}
 
 	// +++++++++++++++++++++++++++++++++++++++++++++
@@ -101,69 +165,38 @@ func ProcessRequest(req *Request, app *app.App) (*http.Response, error) {
 		u.RawQuery = q.Encode()
 	}
 
-	// +++++++++++++++++++++++++++++++++++++++++++++
-	// support for application/x-www-form-urlencoded
-	// +++++++++++++++++++++++++++++++++++++++++++++
-	if req.Headers["Content-Type"] == "application/x-www-form-urlencoded" {
-		// this will have support for single nested layer
-		rawFormData := url.Values{}
-		fmt.Println(req.Body)
-		if reflect.TypeOf(req.Body).Kind() == reflect.Map {
-			for key, val := range req.Body.(map[string]interface{}) {
-				// Note: This structure only works if there is no nested values
-				// we should be iterating if type of value is map or list
-				value, ok := val.(string)
-				if !ok {
-					fmt.Println("[error] parsing body for [application/x-www-form-urlencoded]")
-				}
-				rawFormData.Add(key, value)
-			}
-		}
-		encodedFormData := rawFormData.Encode()
-		httpReq, err := http.NewRequest(req.Method, u.String(), strings.NewReader(encodedFormData))
-		if err != nil {
-			return nil, fmt.Errorf("error creating [application/x-www-form-urlencoded] request %s", err)
-		}
-
-		for key, value := range req.Headers {
-			httpReq.Header.Set(key, value)
-		}
-
-		httpResp, err := client.Do(httpReq)
-		if err != nil {
-			return nil, fmt.Errorf("error making [application/x-www-form-urlencoded] http request %s", err)
-		}
-		return httpResp, nil
-
-	}
-	// +++++++++++++++++++++++++++++++++++++++++++++
-	// json request flow
-	// +++++++++++++++++++++++++++++++++++++++++++++
-	var parsedBodyBytes []byte
-	var err error
-	if req.Body != nil {
-		parsedBodyBytes, err = json.Marshal(req.Body)
-		if err != nil {
-			return nil, fmt.Errorf("error parsing request body %s", err)
-		}
+	bodyReader, contentType, err := buildBody(req)
+	if err != nil {
+		return nil, NewError(ErrorKindValidation, err)
 	}
-	bodyReader := bytes.NewReader(parsedBodyBytes)
 	httpReq, err := http.NewRequest(req.Method, u.String(), bodyReader)
 	if err != nil {
-		return nil, fmt.Errorf("error creating http request %s", err)
+		return nil, Errorf(ErrorKindValidation, "error creating http request %w", err)
 	}
-	startTime := time.Now()
 	for key, value := range req.Headers {
+		// R-* headers are restler controls, they are never sent to the server
+		if isControlHeader(key) {
+			continue
+		}
 		httpReq.Header.Set(key, value)
 	}
-
-	httpResp, err := client.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("error making http request %s", err)
+	if httpReq.Header.Get("Accept-Encoding") == "" {
+		httpReq.Header.Set("Accept-Encoding", utils.AcceptEncoding)
+	}
+	// content type generated while building the body, eg. multipart boundary
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
 	}
 
-	endTime := time.Now()
-	app.RequestTime = endTime.Sub(startTime)
+	httpResp, err := doWithRetry(client, httpReq, requestRetry(req, app), app)
+	if jar, ok := client.Jar.(*CookieJar); ok {
+		if saveErr := jar.Save(); saveErr != nil {
+			fmt.Fprintln(os.Stderr, "[restler Log]: Failed to save cookies: ", saveErr)
+		}
+	}
+	return httpResp, err
+}
 
-	return httpResp, nil
+func isControlHeader(key string) bool {
+	return strings.HasPrefix(http.CanonicalHeaderKey(key), "R-")
 }
@@ -0,0 +1,132 @@
+package svc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shrijan00003/restler/core/app"
+)
+
+func TestControlHeadersAreStripped(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	disabled := false
+	req := &Request{
+		URL:    server.URL,
+		Method: http.MethodGet,
+		Headers: map[string]string{
+			"r-custom-option": "1",
+			"R-Proxy-Enable":  "N",
+			"X-Request-Id":    "42",
+		},
+		Proxy: &Proxy{Enable: &disabled},
+	}
+	res, err := ProcessRequest(req, &app.App{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	for key := range received {
+		if isControlHeader(key) {
+			t.Errorf("control header %s is sent to the server", key)
+		}
+	}
+	if received.Get("X-Request-Id") != "42" {
+		t.Errorf("X-Request-Id = %q, want other headers to be sent", received.Get("X-Request-Id"))
+	}
+}
+
+func TestMigrateProxyHeaders(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"headers.get.yaml": `
+Name: headers
+URL: http://api.example.com
+Method: GET
+Headers:
+  R-Proxy-Enable: N
+  r-proxy-url: http://proxy:8080
+  Accept: application/json
+`,
+		"section.get.yaml": `
+Name: section
+URL: http://api.example.com
+Method: GET
+Headers:
+  R-Proxy-Url: http://old-proxy:8080
+Proxy:
+  URL: http://proxy:3128
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req, err := ParseRequest(filepath.Join(dir, "headers.get.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Proxy == nil || req.Proxy.Enable == nil || *req.Proxy.Enable || req.Proxy.URL != "http://proxy:8080" {
+		t.Errorf("proxy = %+v, want values of the deprecated headers", req.Proxy)
+	}
+	if len(req.Headers) != 1 || req.Headers["Accept"] != "application/json" {
+		t.Errorf("headers = %v, want only Accept", req.Headers)
+	}
+
+	req, err = ParseRequest(filepath.Join(dir, "section.get.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Proxy == nil || req.Proxy.URL != "http://proxy:3128" || req.Proxy.Enable != nil {
+		t.Errorf("proxy = %+v, want Proxy section to have precedence", req.Proxy)
+	}
+	if len(req.Headers) != 0 {
+		t.Errorf("headers = %v, want deprecated header removed", req.Headers)
+	}
+}
+
+func TestRequestProxy(t *testing.T) {
+	clearProxyEnv(t)
+	t.Setenv("HTTPS_PROXY", "http://env-proxy:8080")
+	disabled := false
+	httpReq, _ := http.NewRequest(http.MethodGet, "https://api.example.com", nil)
+
+	tests := []struct {
+		name  string
+		proxy *Proxy
+		want  string
+	}{
+		{"default", nil, "http://env-proxy:8080"},
+		{"disabled", &Proxy{Enable: &disabled}, ""},
+		{"url", &Proxy{URL: "socks5://socks-proxy:1080"}, "socks5://socks-proxy:1080"},
+	}
+	for _, tt := range tests {
+		client, err := newClient(&Request{Proxy: tt.proxy}, &app.App{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if proxyFunc := client.Transport.(*http.Transport).Proxy; proxyFunc != nil {
+			proxyURL, err := proxyFunc(httpReq)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if proxyURL != nil {
+				got = proxyURL.String()
+			}
+		}
+		if got != tt.want {
+			t.Errorf("%s: proxy = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
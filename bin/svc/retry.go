@@ -0,0 +1,188 @@
+package svc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/shrijan00003/restler/core/app"
+)
+
+const (
+	defaultBackoff    = 500 * time.Millisecond
+	defaultMaxBackoff = 30 * time.Second
+)
+
+// default status codes to retry on when StatusCodes is not set
+var defaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// requestRetry merges retry of the request with defaults from config.
+func requestRetry(req *Request, a *app.App) app.Retry {
+	var retry app.Retry
+	if a.Config != nil && a.Config.Retry != nil {
+		retry = *a.Config.Retry
+	}
+	if req.Retry != nil {
+		if req.Retry.MaxAttempts != 0 {
+			retry.MaxAttempts = req.Retry.MaxAttempts
+		}
+		if req.Retry.StatusCodes != nil {
+			retry.StatusCodes = req.Retry.StatusCodes
+		}
+		if req.Retry.Backoff != 0 {
+			retry.Backoff = req.Retry.Backoff
+		}
+		if req.Retry.MaxBackoff != 0 {
+			retry.MaxBackoff = req.Retry.MaxBackoff
+		}
+		if req.Retry.NonIdempotent != nil {
+			retry.NonIdempotent = req.Retry.NonIdempotent
+		}
+	}
+
+	if retry.MaxAttempts < 1 {
+		retry.MaxAttempts = 1
+	}
+	if retry.StatusCodes == nil {
+		retry.StatusCodes = defaultRetryStatusCodes
+	}
+	if retry.Backoff <= 0 {
+		retry.Backoff = defaultBackoff
+	}
+	if retry.MaxBackoff <= 0 {
+		retry.MaxBackoff = defaultMaxBackoff
+	}
+	return retry
+}
+
+// doWithRetry sends the request until it succeeds or max attempts is reached,
+// every attempt is recorded in app.Attempts.
+func doWithRetry(client *http.Client, httpReq *http.Request, retry app.Retry, a *app.App) (*http.Response, error) {
+	// body is read once so that it can be sent again on every attempt
+	var body []byte
+	hasBody := httpReq.Body != nil && httpReq.Body != http.NoBody
+	if hasBody {
+		var err error
+		body, err = io.ReadAll(httpReq.Body)
+		if err != nil {
+			return nil, Errorf(ErrorKindValidation, "error reading request body %w", err)
+		}
+		httpReq.Body.Close()
+	}
+
+	a.Attempts = nil
+	for number := 1; ; number++ {
+		attemptReq := httpReq.Clone(httpReq.Context())
+		if hasBody {
+			attemptReq.Body = io.NopCloser(bytes.NewReader(body))
+			attemptReq.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+		}
+
+		trace := newTracer(&a.Timing)
+		attemptReq = trace.traceRequest(attemptReq)
+
+		startTime := time.Now()
+		httpResp, err := client.Do(attemptReq)
+		a.RequestTime = time.Since(startTime)
+
+		attempt := app.Attempt{Number: number, Duration: a.RequestTime}
+		if err != nil {
+			attempt.Error = err.Error()
+		} else {
+			attempt.StatusCode = httpResp.StatusCode
+		}
+
+		if number >= retry.MaxAttempts || !shouldRetry(httpReq.Method, httpResp, err, retry) {
+			a.Attempts = append(a.Attempts, attempt)
+			if err != nil {
+				trace.done()
+				return nil, Errorf(transportErrorKind(err), "error making http request %w", err)
+			}
+			trace.traceBody(httpResp)
+			return httpResp, nil
+		}
+
+		attempt.Wait = backoff(number, retry, httpResp)
+		a.Attempts = append(a.Attempts, attempt)
+		if httpResp != nil {
+			io.Copy(io.Discard, httpResp.Body)
+			httpResp.Body.Close()
+		}
+		fmt.Fprintf(os.Stderr, "[restler Log]: attempt %d failed, retrying in %s\n", number, attempt.Wait)
+		time.Sleep(attempt.Wait)
+	}
+}
+
+func shouldRetry(method string, res *http.Response, err error, retry app.Retry) bool {
+	if !isIdempotent(method) && (retry.NonIdempotent == nil || !*retry.NonIdempotent) {
+		return false
+	}
+	if err != nil {
+		// certificate and handshake errors fail the same way on every attempt
+		return !isTLSError(err)
+	}
+	for _, code := range retry.StatusCodes {
+		if res.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// isIdempotent is true for the methods which can be sent again without
+// changing the result, RFC 9110 section 9.2.2.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPatch, http.MethodConnect:
+		return false
+	}
+	return true
+}
+
+// backoff returns wait time before next attempt, Retry-After header from the
+// response is honored up to MaxBackoff, otherwise it is exponential backoff
+// with jitter.
+func backoff(attempt int, retry app.Retry, res *http.Response) time.Duration {
+	if res != nil {
+		if wait, ok := retryAfter(res.Header.Get("Retry-After")); ok {
+			return min(wait, retry.MaxBackoff)
+		}
+	}
+
+	wait := retry.Backoff << (attempt - 1)
+	if wait <= 0 || wait > retry.MaxBackoff {
+		wait = retry.MaxBackoff
+	}
+	// equal jitter, half of the wait is random
+	half := wait / 2
+	return half + time.Duration(rnd.Int63n(int64(half)+1))
+}
+
+// retryAfter parses Retry-After header which is either seconds or http date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
@@ -0,0 +1,150 @@
+package svc
+
+import (
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shrijan00003/restler/core/app"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"5", 5 * time.Second, true},
+		{"0", 0, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %s, %v, want %s, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	retry := app.Retry{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for attempt := 1; attempt <= 10; attempt++ {
+		wait := backoff(attempt, retry, nil)
+		max := min(retry.Backoff<<(attempt-1), retry.MaxBackoff)
+		if wait < max/2 || wait > max {
+			t.Errorf("backoff of attempt %d = %s, want between %s and %s", attempt, wait, max/2, max)
+		}
+	}
+
+	res := &http.Response{Header: http.Header{"Retry-After": []string{"3600"}}}
+	if wait := backoff(1, retry, res); wait != time.Second {
+		t.Errorf("backoff with Retry-After 3600 = %s, want MaxBackoff 1s", wait)
+	}
+	res.Header.Set("Retry-After", "0")
+	if wait := backoff(1, retry, res); wait != 0 {
+		t.Errorf("backoff with Retry-After 0 = %s, want 0", wait)
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	enabled := true
+	retry := app.Retry{StatusCodes: defaultRetryStatusCodes}
+	nonIdempotent := retry
+	nonIdempotent.NonIdempotent = &enabled
+	transportErr := errors.New("connection reset by peer")
+	tlsErr := x509.UnknownAuthorityError{}
+
+	tests := []struct {
+		name   string
+		method string
+		status int
+		err    error
+		retry  app.Retry
+		want   bool
+	}{
+		{"retry status", http.MethodGet, http.StatusServiceUnavailable, nil, retry, true},
+		{"success", http.MethodGet, http.StatusOK, nil, retry, false},
+		{"other status", http.MethodGet, http.StatusInternalServerError, nil, retry, false},
+		{"transport error", http.MethodPut, 0, transportErr, retry, true},
+		{"tls error", http.MethodGet, 0, tlsErr, retry, false},
+		{"post", http.MethodPost, http.StatusServiceUnavailable, nil, retry, false},
+		{"patch transport error", http.MethodPatch, 0, transportErr, retry, false},
+		{"post opted in", http.MethodPost, 0, transportErr, nonIdempotent, true},
+	}
+	for _, tt := range tests {
+		var res *http.Response
+		if tt.err == nil {
+			res = &http.Response{StatusCode: tt.status}
+		}
+		if got := shouldRetry(tt.method, res, tt.err, tt.retry); got != tt.want {
+			t.Errorf("%s: shouldRetry = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRequestRetry(t *testing.T) {
+	enabled, disabled := true, false
+	a := &app.App{Config: &app.Config{Retry: &app.Retry{MaxAttempts: 3, Backoff: time.Second, NonIdempotent: &enabled}}}
+	req := &Request{Retry: &app.Retry{MaxBackoff: 5 * time.Second, NonIdempotent: &disabled}}
+
+	retry := requestRetry(req, a)
+	if retry.MaxAttempts != 3 || retry.Backoff != time.Second || retry.MaxBackoff != 5*time.Second {
+		t.Errorf("requestRetry = %+v, want values of config and request merged", retry)
+	}
+	if retry.NonIdempotent == nil || *retry.NonIdempotent {
+		t.Errorf("NonIdempotent of the request should override config")
+	}
+	if len(retry.StatusCodes) != len(defaultRetryStatusCodes) {
+		t.Errorf("StatusCodes = %v, want defaults", retry.StatusCodes)
+	}
+}
+
+func TestDoWithRetry(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) < 3 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	retry := app.Retry{MaxAttempts: 3, StatusCodes: defaultRetryStatusCodes, Backoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+	send := func(method string) (*http.Response, *app.App) {
+		httpReq, err := http.NewRequest(method, server.URL, strings.NewReader("body"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		a := &app.App{}
+		res, err := doWithRetry(server.Client(), httpReq, retry, a)
+		if err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+		res.Body.Close()
+		return res, a
+	}
+
+	res, a := send(http.MethodPut)
+	if res.StatusCode != http.StatusOK || len(a.Attempts) != 3 {
+		t.Errorf("PUT got %d after %d attempts, want 200 after 3", res.StatusCode, len(a.Attempts))
+	}
+	if wait := a.Attempts[0].Wait; wait != retry.MaxBackoff {
+		t.Errorf("wait of Retry-After 3600 = %s, want MaxBackoff", wait)
+	}
+
+	atomic.StoreInt32(&hits, 0)
+	res, a = send(http.MethodPost)
+	if res.StatusCode != http.StatusServiceUnavailable || len(a.Attempts) != 1 {
+		t.Errorf("POST got %d after %d attempts, want 503 after 1", res.StatusCode, len(a.Attempts))
+	}
+}
@@ -0,0 +1,129 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shrijan00003/restler/core/app"
+	"github.com/shrijan00003/restler/core/utils"
+)
+
+// RunOptions of running a request file.
+type RunOptions struct {
+	Version string
+	// Raw keeps the body as it is sent by the server instead of pretty printing.
+	Raw      bool
+	SortKeys bool
+}
+
+// RunRequest parses and sends the request file, reads the response and checks
+// it against Assert and Schema of the request. Timing and attempts of the
+// request are recorded in a, requests running in parallel need their own copy
+// of the app.
+func RunRequest(reqPath string, a *app.App, opts RunOptions) (*Response, error) {
+	req, err := ParseRequest(reqPath)
+	if err != nil {
+		return nil, err
+	}
+
+	startedAt := time.Now()
+	httpRes, err := ProcessRequest(req, a)
+	if err != nil {
+		return nil, &Error{Kind: ErrorKindOf(err), Path: reqPath, Err: err}
+	}
+
+	rawBody, err := utils.ReadRawBody(httpRes)
+	if err != nil {
+		return nil, Errorf(ErrorKindResponse, "error reading response body %w", err)
+	}
+
+	body := rawBody
+	if utils.HasBody(httpRes) {
+		body, err = utils.DecodeBody(rawBody, utils.ContentEncodings(httpRes.Header))
+		if errors.Is(err, utils.ErrUnsupportedEncoding) {
+			// body is still useful eg. to see the error of the server
+			fmt.Fprintf(os.Stderr, "[restler warning]: %s, body is kept as it is sent\n", err)
+			body, err = rawBody, nil
+		}
+		if err != nil {
+			return nil, Errorf(ErrorKindResponse, "error decoding response body %w", err)
+		}
+	}
+
+	media := utils.DetectMedia(httpRes.Header, body)
+	body, err = utils.ToUTF8(body, media)
+	if err != nil {
+		return nil, Errorf(ErrorKindResponse, "error decoding response charset %w", err)
+	}
+
+	res := &Response{
+		Version:   opts.Version,
+		Request:   req,
+		HTTP:      httpRes,
+		StartedAt: startedAt,
+		Timing:    a.Timing,
+		Attempts:  a.Attempts,
+		RawBody:   rawBody,
+		Body:      body,
+		Display:   body,
+		Media:     media,
+		File:      responseFile(reqPath, req.Method),
+	}
+	// binary body is saved next to the response file instead of inlining it
+	if media.Binary {
+		res.BodyFile = filepath.Base(res.File) + utils.FileExtension(media.MediaType)
+	} else if !opts.Raw {
+		res.Display = utils.PrettyBody(body, media.MediaType, utils.PrettyOptions{SortKeys: opts.SortKeys})
+	}
+
+	if err := EvaluateResponse(res); err != nil {
+		return nil, &Error{Kind: ErrorKindOf(err), Path: reqPath, Err: err}
+	}
+	return res, nil
+}
+
+// responseFile is the path of the response files without extension, files of
+// all formats share it eg. posts/.res.posts/.posts.get.20240101120000000000.res
+func responseFile(reqPath string, method string) string {
+	baseName := filepath.Base(reqPath)
+	outName := strings.TrimSuffix(baseName, filepath.Ext(baseName))
+	dir := filepath.Join(filepath.Dir(reqPath), ".res."+outName)
+	name := fmt.Sprintf(".%s.%s.%s.res", outName, strings.ToLower(method), strings.Replace(time.Now().Format("20060102150405.000000"), ".", "", 1))
+	return filepath.Join(dir, name)
+}
+
+// SaveResponse writes the response file of every formatter, the binary body
+// and the raw compressed body when keepRaw is set.
+func SaveResponse(res *Response, formatters []Formatter, keepRaw bool) error {
+	dir := filepath.Dir(res.File)
+	if err := os.Mkdir(dir, 0755); err != nil && !os.IsExist(err) {
+		return Errorf(ErrorKindFile, "error creating response folder %w", err)
+	}
+	if res.BodyFile != "" {
+		if err := os.WriteFile(filepath.Join(dir, res.BodyFile), res.Body, 0644); err != nil {
+			return Errorf(ErrorKindFile, "error writing response body file %w", err)
+		}
+	}
+
+	for _, formatter := range formatters {
+		responseBytes, err := formatter.Format(res)
+		if err != nil {
+			return fmt.Errorf("error writing response file %w", err)
+		}
+		if err := os.WriteFile(res.File+formatter.Extension(), responseBytes, 0644); err != nil {
+			return Errorf(ErrorKindFile, "error writing response file %w", err)
+		}
+	}
+
+	// raw compressed bytes are kept next to the response file
+	if keepRaw && len(utils.ContentEncodings(res.HTTP.Header)) > 0 {
+		if err := os.WriteFile(res.File+".raw", res.RawBody, 0644); err != nil {
+			return Errorf(ErrorKindFile, "error writing raw response file %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package svc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is the JSON Schema (draft 2020-12 by default) of the response body,
+// it is a path of the schema file relative to the request file or the schema
+// itself eg.
+//
+//	Schema: ../schemas/post.json
+//
+//	Schema:
+//	  type: object
+//	  required: [id, title]
+type Schema struct {
+	Path   string      `yaml:"-"`
+	Inline interface{} `yaml:"-"`
+}
+
+func (s *Schema) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!str" {
+		s.Path = node.Value
+		if s.Path == "" {
+			return fmt.Errorf("schema path is empty")
+		}
+		return nil
+	}
+	if node.Kind != yaml.MappingNode && node.ShortTag() != "!!bool" {
+		return fmt.Errorf("schema should be a path of the schema file or the schema as a map")
+	}
+	return node.Decode(&s.Inline)
+}
+
+func (s Schema) MarshalYAML() (interface{}, error) {
+	if s.Path != "" {
+		return s.Path, nil
+	}
+	return s.Inline, nil
+}
+
+func (s *Schema) String() string {
+	if s.Path != "" {
+		return s.Path
+	}
+	return "inline"
+}
+
+// SchemaViolation is a single error of the body against the schema.
+type SchemaViolation struct {
+	// Pointer is the JSON pointer of the value in the body eg. /items/0/id
+	Pointer string `json:"pointer" yaml:"pointer"`
+	// Keyword is the JSON pointer of the keyword in the schema eg. /properties/id/type
+	Keyword string `json:"keyword" yaml:"keyword"`
+	Message string `json:"message" yaml:"message"`
+}
+
+var schemaPrinter = message.NewPrinter(language.English)
+
+// inlineSchemaName is the name of the inline schema, $ref of the inline schema
+// is resolved relative to the request file.
+const inlineSchemaName = ".restler-inline-schema.json"
+
+// compile loads the schema, dir is the directory of the request file.
+func (s *Schema) compile(dir string) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+
+	location := s.Path
+	if location == "" {
+		location = inlineSchemaName
+		// yaml values are converted to json values of the schema library
+		encoded, err := json.Marshal(s.Inline)
+		if err != nil {
+			return nil, Errorf(ErrorKindValidation, "invalid schema: %w", err)
+		}
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(encoded))
+		if err != nil {
+			return nil, Errorf(ErrorKindValidation, "invalid schema: %w", err)
+		}
+		if err := compiler.AddResource(filepath.Join(dir, location), doc); err != nil {
+			return nil, Errorf(ErrorKindValidation, "invalid schema: %w", err)
+		}
+	}
+	if !filepath.IsAbs(location) && !strings.Contains(location, "://") {
+		location = filepath.Join(dir, location)
+	}
+
+	schema, err := compiler.Compile(location)
+	var loadErr *jsonschema.LoadURLError
+	if errors.As(err, &loadErr) {
+		return nil, Errorf(ErrorKindFile, "error loading schema %s: %w", s, loadErr.Err)
+	}
+	if err != nil {
+		return nil, Errorf(ErrorKindValidation, "error loading schema %s: %w", s, err)
+	}
+	return schema, nil
+}
+
+// Validate validates the body against the schema, violations are sorted by
+// the pointer of the body. Error is returned when the schema can't be loaded.
+func (s *Schema) Validate(dir string, body []byte) ([]SchemaViolation, error) {
+	schema, err := s.compile(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// numbers are kept as json.Number so that big numbers are validated exactly
+	value, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	if err != nil {
+		return []SchemaViolation{{Pointer: "", Message: fmt.Sprintf("body is not in JSON format: %s", err)}}, nil
+	}
+
+	err = schema.Validate(value)
+	if err == nil {
+		return nil, nil
+	}
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return nil, Errorf(ErrorKindValidation, "error validating schema %s: %w", s, err)
+	}
+	violations := schemaViolations(schema.Location, validationErr, nil)
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Pointer < violations[j].Pointer
+	})
+	return violations, nil
+}
+
+// schemaViolations returns the leaf errors, parent errors like "allOf failed"
+// don't say what is wrong
+func schemaViolations(root string, err *jsonschema.ValidationError, violations []SchemaViolation) []SchemaViolation {
+	if len(err.Causes) == 0 {
+		return append(violations, SchemaViolation{
+			Pointer: jsonPointer(err.InstanceLocation),
+			Keyword: schemaKeyword(root, err),
+			Message: err.ErrorKind.LocalizedString(schemaPrinter),
+		})
+	}
+	for _, cause := range err.Causes {
+		violations = schemaViolations(root, cause, violations)
+	}
+	return violations
+}
+
+// schemaKeyword is the pointer of the keyword in the schema, keywords of the
+// schemas referenced with $ref have the name of their file eg. item.json#/type
+func schemaKeyword(root string, err *jsonschema.ValidationError) string {
+	file, fragment, _ := strings.Cut(err.SchemaURL, "#")
+	rootFile, _, _ := strings.Cut(root, "#")
+	keyword := fragment + jsonPointer(err.ErrorKind.KeywordPath())
+	if file != rootFile {
+		return path.Base(file) + "#" + keyword
+	}
+	return keyword
+}
+
+// DisplayPointer is the pointer for messages, empty pointer of the whole body
+// is shown as (root).
+func (v SchemaViolation) DisplayPointer() string {
+	if v.Pointer == "" {
+		return "(root)"
+	}
+	return v.Pointer
+}
+
+func jsonPointer(path []string) string {
+	var sb strings.Builder
+	for _, token := range path {
+		sb.WriteString("/")
+		sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return sb.String()
+}
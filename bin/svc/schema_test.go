@@ -0,0 +1,103 @@
+package svc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRequestInlineSchema(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("API_URL", "http://localhost:3000")
+	// defined variables are not expanded in the inline schema either
+	t.Setenv("defs", "expanded")
+	reqPath := filepath.Join(dir, "posts.get.yaml")
+	content := `
+Name: Get Posts
+URL: "{{API_URL}}/posts"
+Method: GET
+Schema:
+  $id: https://example.com/posts.json
+  type: array
+  items: { $ref: "#/$defs/post" }
+  $defs:
+    post:
+      type: object
+      required: [id]
+      properties:
+        id: { type: integer }
+`
+	if err := os.WriteFile(reqPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := ParseRequest(reqPath)
+	if err != nil {
+		t.Fatalf("ParseRequest error: %v", err)
+	}
+	if req.URL != "http://localhost:3000/posts" {
+		t.Errorf("URL = %q, want it expanded", req.URL)
+	}
+
+	violations, err := req.Schema.Validate(req.Dir, []byte(`[{"id": 1}, {"id": "2"}, {}]`))
+	if err != nil {
+		t.Fatalf("Validate error: %v", err)
+	}
+	want := []SchemaViolation{
+		{Pointer: "/1/id", Keyword: "/$defs/post/properties/id/type"},
+		{Pointer: "/2", Keyword: "/$defs/post/required"},
+	}
+	if len(violations) != len(want) {
+		t.Fatalf("violations = %+v, want %d", violations, len(want))
+	}
+	for i, violation := range violations {
+		if violation.Pointer != want[i].Pointer || violation.Keyword != want[i].Keyword {
+			t.Errorf("violation %d = %s %s, want %s %s", i, violation.Pointer, violation.Keyword, want[i].Pointer, want[i].Keyword)
+		}
+	}
+}
+
+func TestSchemaFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SCHEMA_DIR", "schemas")
+	writeFiles(t, dir, map[string]string{
+		"schemas/post.json": `{"type": "object", "properties": {"user": {"$ref": "user.json"}}}`,
+		"schemas/user.json": `{"type": "object", "required": ["name"]}`,
+		"posts.get.yaml":    "Name: Get\nURL: http://localhost\nMethod: GET\nSchema: \"{{SCHEMA_DIR}}/post.json\"\n",
+	})
+
+	req, err := ParseRequest(filepath.Join(dir, "posts.get.yaml"))
+	if err != nil {
+		t.Fatalf("ParseRequest error: %v", err)
+	}
+	violations, err := req.Schema.Validate(req.Dir, []byte(`{"user": {}}`))
+	if err != nil {
+		t.Fatalf("Validate error: %v", err)
+	}
+	if len(violations) != 1 || violations[0].Pointer != "/user" || violations[0].Keyword != "user.json#/required" {
+		t.Errorf("violations = %+v, want /user user.json#/required", violations)
+	}
+
+	violations, err = req.Schema.Validate(req.Dir, []byte(`not json`))
+	if err != nil || len(violations) != 1 || violations[0].DisplayPointer() != "(root)" {
+		t.Errorf("non json body violations = %+v, %v, want a root violation", violations, err)
+	}
+
+	missing := &Schema{Path: "missing.json"}
+	if _, err := missing.Validate(dir, []byte(`{}`)); ErrorKindOf(err) != ErrorKindFile {
+		t.Errorf("missing schema error = %v, want file error", err)
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
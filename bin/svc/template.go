@@ -0,0 +1,412 @@
+package svc
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// random source used by dynamic variables, it can be seeded with --seed flag
+// to get reproducible values in tests.
+var rnd = &lockedRand{r: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+func SetSeed(seed int64) {
+	rnd.mu.Lock()
+	defer rnd.mu.Unlock()
+	rnd.r = rand.New(rand.NewSource(seed))
+}
+
+// lockedRand is safe for the requests running in parallel with restler test
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (l *lockedRand) Intn(n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Intn(n)
+}
+
+func (l *lockedRand) Int63n(n int64) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Int63n(n)
+}
+
+func (l *lockedRand) Read(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Read(p)
+}
+
+type dynamicVarFunc func(args string) (string, error)
+
+// dynamicVars are built in variables available in the request templates,
+// inspired by postman dynamic variables.
+var dynamicVars = map[string]dynamicVarFunc{
+	"guid":               func(string) (string, error) { return randomUUID(), nil },
+	"randomUUID":         func(string) (string, error) { return randomUUID(), nil },
+	"timestamp":          timestamp,
+	"timestampMs":        func(string) (string, error) { return timestamp("ms") },
+	"isoTimestamp":       func(string) (string, error) { return timestamp("iso") },
+	"randomInt":          randomInt,
+	"randomBoolean":      func(string) (string, error) { return strconv.FormatBool(rnd.Intn(2) == 1), nil },
+	"randomFirstName":    func(string) (string, error) { return pick(firstNames), nil },
+	"randomLastName":     func(string) (string, error) { return pick(lastNames), nil },
+	"randomFullName":     func(string) (string, error) { return pick(firstNames) + " " + pick(lastNames), nil },
+	"randomEmail":        randomEmail,
+	"randomAlphaNumeric": randomString,
+	"base64": func(args string) (string, error) {
+		return base64.StdEncoding.EncodeToString([]byte(args)), nil
+	},
+	"base64Decode": func(args string) (string, error) {
+		decoded, err := base64.StdEncoding.DecodeString(args)
+		return string(decoded), err
+	},
+	"urlEncode": func(args string) (string, error) { return url.QueryEscape(args), nil },
+}
+
+// allowUndefined will only warn for undefined variables instead of failing
+var allowUndefined = false
+
+func SetAllowUndefined(allow bool) {
+	allowUndefined = allow
+}
+
+// bareVars enables shell like $VAR and $$ escape, it is off by default so that
+// values like bcrypt hashes, prices and mongo operators are sent as they are
+var bareVars = false
+
+func SetBareVars(enable bool) {
+	bareVars = enable
+}
+
+// ExpandTemplate replaces variables in the input, supported syntax are
+//
+//	{{VAR}}, ${VAR}                      env variable
+//	{{VAR:-fallback}}, ${VAR:-fallback}  env variable with default value
+//	{{$name args}}                       dynamic variable eg. {{$randomInt 1 10}}
+//	\{{, \${                             literal "{{" and "${"
+//	$VAR, $$                             env variable and literal "$", only with SetBareVars
+//
+// Undefined variables are error unless it is allowed with SetAllowUndefined,
+// except $VAR which is kept as it is.
+func ExpandTemplate(input string) (string, error) {
+	t := &templateExpander{}
+	output := t.expand(input)
+	return output, t.finish()
+}
+
+// LiteralTag marks a yaml value which should never be expanded eg.
+//
+//	password: !literal pa$$word{{1}}
+const LiteralTag = "!literal"
+
+// expandNode expands string values of the parsed yaml, it runs after parsing
+// so that substituted values can't break the yaml structure, and only values
+// are expanded (keys like mongo's $set are kept as it is).
+func expandNode(node *yaml.Node) error {
+	t := &templateExpander{}
+	t.expandNode(node)
+	return t.finish()
+}
+
+type templateExpander struct {
+	undefined []string
+	err       error
+}
+
+func (t *templateExpander) finish() error {
+	if len(t.undefined) > 0 {
+		if !allowUndefined {
+			return fmt.Errorf("undefined variables: %s, set them in env or use default value like {{%s:-value}}", strings.Join(t.undefined, ", "), t.undefined[0])
+		}
+		fmt.Fprintln(os.Stderr, "[restler warning]: undefined variables replaced with empty value:", strings.Join(t.undefined, ", "))
+	}
+	return t.err
+}
+
+func (t *templateExpander) expandNode(node *yaml.Node) {
+	if node.Tag == LiteralTag {
+		node.Tag = ""
+		node.Style &^= yaml.TaggedStyle
+		if node.Kind == yaml.ScalarNode {
+			node.Tag = "!!str"
+		}
+		return
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			t.expandNode(child)
+		}
+	case yaml.MappingNode:
+		// only values are expanded, keys are kept as it is
+		for i := 1; i < len(node.Content); i += 2 {
+			t.expandNode(node.Content[i])
+		}
+	case yaml.ScalarNode:
+		if node.ShortTag() != "!!str" {
+			return
+		}
+		expanded := t.expand(node.Value)
+		if expanded == node.Value {
+			return
+		}
+		node.Value = expanded
+		// plain values are resolved again so that `count: ${COUNT}` is still
+		// a number, quoted values are always string
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+	}
+}
+
+func (t *templateExpander) expand(input string) string {
+	var b strings.Builder
+	for i := 0; i < len(input); {
+		rest := input[i:]
+		switch {
+		case strings.HasPrefix(rest, `\{{`):
+			b.WriteString("{{")
+			i += 3
+		case strings.HasPrefix(rest, `\${`):
+			b.WriteString("${")
+			i += 3
+		case bareVars && strings.HasPrefix(rest, "$$"):
+			b.WriteByte('$')
+			i += 2
+		case strings.HasPrefix(rest, "{{"):
+			end := closingBraces(rest)
+			if end < 0 {
+				b.WriteString(rest)
+				return b.String()
+			}
+			b.WriteString(t.expandExpr(rest[2:end], true))
+			i += end + 2
+		case strings.HasPrefix(rest, "${"):
+			end := strings.IndexByte(rest, '}')
+			if end < 0 {
+				b.WriteString(rest)
+				return b.String()
+			}
+			b.WriteString(t.expandExpr(rest[2:end], false))
+			i += end + 1
+		case bareVars && rest[0] == '$' && len(rest) > 1 && isNameStart(rest[1]):
+			end := 2
+			for end < len(rest) && isNameChar(rest[end]) {
+				end++
+			}
+			// undefined $NAME is kept as it is, values like odata queries
+			// ($filter) are not variables
+			if value, ok := os.LookupEnv(rest[1:end]); ok {
+				b.WriteString(value)
+			} else {
+				b.WriteString(rest[:end])
+			}
+			i += end
+		default:
+			b.WriteByte(input[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// expandExpr expands content of {{ }} or ${ } ie. NAME, NAME:-default or
+// $dynamic args (only inside {{ }})
+func (t *templateExpander) expandExpr(expr string, mustache bool) string {
+	trimmed := strings.TrimSpace(expr)
+	if mustache && strings.HasPrefix(trimmed, "$") {
+		return t.dynamic(trimmed[1:])
+	}
+	name, fallback, hasDefault := strings.Cut(trimmed, ":-")
+	name = strings.TrimSpace(name)
+	if !isName(name) {
+		// not a variable, keep it as it is eg. {{ some text }}
+		if mustache {
+			return "{{" + expr + "}}"
+		}
+		return "${" + expr + "}"
+	}
+	return t.lookup(name, t.expand(fallback), hasDefault)
+}
+
+func (t *templateExpander) lookup(name string, fallback string, hasDefault bool) string {
+	if value, ok := os.LookupEnv(name); ok {
+		return value
+	}
+	if hasDefault {
+		return fallback
+	}
+	for _, undefined := range t.undefined {
+		if undefined == name {
+			return ""
+		}
+	}
+	t.undefined = append(t.undefined, name)
+	return ""
+}
+
+func (t *templateExpander) dynamic(expr string) string {
+	name, args, _ := strings.Cut(expr, " ")
+	fn, ok := dynamicVars[name]
+	if !ok {
+		if t.err == nil {
+			t.err = fmt.Errorf("unknown dynamic variable {{$%s}}", name)
+		}
+		return ""
+	}
+	// arguments can use variables eg. {{$base64 {{USER}}:{{PASSWORD}}}}
+	value, err := fn(t.expand(strings.TrimSpace(args)))
+	if err != nil && t.err == nil {
+		t.err = fmt.Errorf("error evaluating {{$%s}}: %w", name, err)
+	}
+	return value
+}
+
+// closingBraces returns index of }} matching the {{ at the start of input
+func closingBraces(input string) int {
+	depth := 0
+	for i := 0; i < len(input)-1; i++ {
+		switch input[i : i+2] {
+		case "{{":
+			depth++
+			i++
+		case "}}":
+			depth--
+			if depth == 0 {
+				return i
+			}
+			i++
+		}
+	}
+	return -1
+}
+
+func isName(s string) bool {
+	if s == "" || !isNameStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isNameChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || ('0' <= c && c <= '9')
+}
+
+func randomUUID() string {
+	var b [16]byte
+	rnd.Read(b[:])
+	// version 4 and variant bits as per RFC 4122
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// timestamp supports unix (default), ms, iso, rfc1123 or any go time layout
+// eg. {{$timestamp 2006-01-02}}
+func timestamp(format string) (string, error) {
+	now := time.Now()
+	switch format {
+	case "", "unix":
+		return strconv.FormatInt(now.Unix(), 10), nil
+	case "ms":
+		return strconv.FormatInt(now.UnixMilli(), 10), nil
+	case "iso":
+		return now.UTC().Format("2006-01-02T15:04:05.000Z"), nil
+	case "rfc1123":
+		return now.UTC().Format(time.RFC1123), nil
+	default:
+		return now.Format(format), nil
+	}
+}
+
+// randomInt returns random int between 0 and 1000, range can be given as
+// {{$randomInt 10 20}} or the max only as {{$randomInt 20}}
+func randomInt(args string) (string, error) {
+	min, max := 0, 1000
+	fields := strings.Fields(args)
+	var err error
+	switch len(fields) {
+	case 0:
+	case 1:
+		max, err = strconv.Atoi(fields[0])
+	case 2:
+		min, err = strconv.Atoi(fields[0])
+		if err == nil {
+			max, err = strconv.Atoi(fields[1])
+		}
+	default:
+		return "", fmt.Errorf("expected at most 2 arguments, got %d", len(fields))
+	}
+	if err != nil {
+		return "", err
+	}
+	if max < min {
+		return "", fmt.Errorf("max %d is less than min %d", max, min)
+	}
+	// span overflows when the range is wider than the largest int
+	span := max - min
+	if span < 0 || span == math.MaxInt {
+		return "", fmt.Errorf("range %d to %d is too large", min, max)
+	}
+	return strconv.Itoa(min + rnd.Intn(span+1)), nil
+}
+
+func randomEmail(string) (string, error) {
+	return fmt.Sprintf("%s.%s%d@%s", strings.ToLower(pick(firstNames)), strings.ToLower(pick(lastNames)), rnd.Intn(1000), pick(emailDomains)), nil
+}
+
+const alphaNumeric = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// maxRandomLength of {{$randomAlphaNumeric}}, longer values are rather a
+// typo than a value someone wants in the request
+const maxRandomLength = 10000
+
+func randomString(args string) (string, error) {
+	length := 10
+	if args != "" {
+		var err error
+		length, err = strconv.Atoi(args)
+		if err != nil {
+			return "", err
+		}
+	}
+	if length < 0 || length > maxRandomLength {
+		return "", fmt.Errorf("length should be between 0 and %d, got %d", maxRandomLength, length)
+	}
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = alphaNumeric[rnd.Intn(len(alphaNumeric))]
+	}
+	return string(b), nil
+}
+
+func pick(values []string) string {
+	return values[rnd.Intn(len(values))]
+}
+
+var firstNames = []string{"John", "Jane", "Alex", "Sam", "Maria", "Aarav", "Sita", "Liam", "Olivia", "Noah", "Emma", "Chen", "Yuki", "Omar", "Fatima"}
+var lastNames = []string{"Doe", "Smith", "Shrestha", "Johnson", "Garcia", "Tanaka", "Khan", "Brown", "Miller", "Wilson", "Lee", "Sharma"}
+var emailDomains = []string{"example.com", "example.org", "example.net"}
@@ -0,0 +1,282 @@
+package svc
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	t.Setenv("API_URL", "http://localhost:3000")
+	t.Setenv("USER", "admin")
+	t.Setenv("EMPTY", "")
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"mustache", "{{API_URL}}/posts", "http://localhost:3000/posts"},
+		{"mustache with spaces", "{{ API_URL }}/posts", "http://localhost:3000/posts"},
+		{"braces", "${API_URL}/posts", "http://localhost:3000/posts"},
+		{"defined empty", "[{{EMPTY:-fallback}}]", "[]"},
+		{"mustache default", "{{MISSING:-http://localhost:8080}}", "http://localhost:8080"},
+		{"braces default", "${MISSING:-none}", "none"},
+		{"default with variable", "{{MISSING:-{{USER}}}}", "admin"},
+		{"escaped braces", `\{{name}}`, "{{name}}"},
+		{"escaped dollar braces", `\${API_URL}`, "${API_URL}"},
+		{"not a variable", "{{ some text }}", "{{ some text }}"},
+		{"not a variable braces", "${1abc}", "${1abc}"},
+		{"unclosed mustache", "{{API_URL", "{{API_URL"},
+		{"unclosed braces", "${API_URL", "${API_URL"},
+		{"dollar alone", "cost $ 10", "cost $ 10"},
+		{"bare is kept", "$API_URL/posts", "$API_URL/posts"},
+		{"double dollar is kept", "pa$$word", "pa$$word"},
+		{"price", "$10.99", "$10.99"},
+		{"bcrypt hash", "$2b$12$R9hqcIMUhV2", "$2b$12$R9hqcIMUhV2"},
+		{"mongo operator", `{"$set": {"name": "$USER"}}`, `{"$set": {"name": "$USER"}}`},
+		{"dynamic with variable args", "{{$base64 {{USER}}:secret}}", "YWRtaW46c2VjcmV0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandTemplate(tt.input)
+			if err != nil {
+				t.Fatalf("ExpandTemplate(%q) error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ExpandTemplate(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"undefined mustache", "{{MISSING}}", "undefined variables: MISSING"},
+		{"undefined braces", "${MISSING}", "undefined variables: MISSING"},
+		{"undefined listed once", "{{MISSING}}{{MISSING}}{{OTHER}}", "undefined variables: MISSING, OTHER"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ExpandTemplate(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ExpandTemplate(%q) error = %v, want %q", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExpandTemplateBareVars(t *testing.T) {
+	SetBareVars(true)
+	defer SetBareVars(false)
+	t.Setenv("API_URL", "http://localhost:3000")
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"bare", "$API_URL/posts", "http://localhost:3000/posts"},
+		{"escaped dollar", "$$10", "$10"},
+		{"undefined bare is kept", "?$filter=a&$top=10", "?$filter=a&$top=10"},
+		{"bcrypt hash", "$2b$12$R9hqcIMUhV2", "$2b$12$R9hqcIMUhV2"},
+		{"mustache", "{{API_URL}}", "http://localhost:3000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandTemplate(tt.input)
+			if err != nil {
+				t.Fatalf("ExpandTemplate(%q) error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ExpandTemplate(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandTemplateAllowUndefined(t *testing.T) {
+	SetAllowUndefined(true)
+	defer SetAllowUndefined(false)
+
+	got, err := ExpandTemplate("a{{MISSING}}b")
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	if got != "ab" {
+		t.Errorf("got %q, want %q", got, "ab")
+	}
+}
+
+func TestExpandNode(t *testing.T) {
+	t.Setenv("COUNT", "3")
+	t.Setenv("TOKEN", "abc")
+	t.Setenv("set", "oops")
+
+	input := `
+count: ${COUNT}
+quoted: "${COUNT}"
+$set: "{{TOKEN}}"
+update: {$set: {name: $set}}
+password: !literal pa${TOKEN}{{1}}
+hash: $2b$12$R9hqcIMUhV2
+list: [$TOKEN, "{{TOKEN}}"]
+`
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(input), &root); err != nil {
+		t.Fatal(err)
+	}
+	if err := expandNode(&root); err != nil {
+		t.Fatalf("expandNode error: %v", err)
+	}
+	var got map[string]interface{}
+	if err := root.Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got["count"] != 3 {
+		t.Errorf("count = %#v, want number 3", got["count"])
+	}
+	if got["quoted"] != "3" {
+		t.Errorf("quoted = %#v, want string 3", got["quoted"])
+	}
+	if got["$set"] != "abc" {
+		t.Errorf("$set = %#v, want abc", got["$set"])
+	}
+	update, _ := got["update"].(map[string]interface{})
+	if set, _ := update["$set"].(map[string]interface{}); set["name"] != "$set" {
+		t.Errorf("update = %#v, want $set kept", got["update"])
+	}
+	if got["password"] != "pa${TOKEN}{{1}}" {
+		t.Errorf("password = %#v, want it unchanged", got["password"])
+	}
+	if got["hash"] != "$2b$12$R9hqcIMUhV2" {
+		t.Errorf("hash = %#v, want it unchanged", got["hash"])
+	}
+	list, _ := got["list"].([]interface{})
+	if len(list) != 2 || list[0] != "$TOKEN" || list[1] != "abc" {
+		t.Errorf("list = %#v, want [$TOKEN abc]", got["list"])
+	}
+}
+
+func TestClosingBraces(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"{{A}}", 3},
+		{"{{$base64 {{A}}:{{B}}}} rest", 21},
+		{"{{A", -1},
+		{"{{A}", -1},
+	}
+	for _, tt := range tests {
+		if got := closingBraces(tt.input); got != tt.want {
+			t.Errorf("closingBraces(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestDynamicVars(t *testing.T) {
+	tests := []struct {
+		input string
+		want  *regexp.Regexp
+	}{
+		{"{{$guid}}", regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)},
+		{"{{$randomUUID}}", regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)},
+		{"{{$timestamp}}", regexp.MustCompile(`^\d{10}$`)},
+		{"{{$timestampMs}}", regexp.MustCompile(`^\d{13}$`)},
+		{"{{$isoTimestamp}}", regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}Z$`)},
+		{"{{$timestamp 2006-01-02}}", regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)},
+		{"{{$randomBoolean}}", regexp.MustCompile(`^(true|false)$`)},
+		{"{{$randomEmail}}", regexp.MustCompile(`^[a-z]+\.[a-z]+\d+@example\.(com|org|net)$`)},
+		{"{{$randomFullName}}", regexp.MustCompile(`^[A-Z][a-z]+ [A-Z][a-z]+$`)},
+		{"{{$randomAlphaNumeric}}", regexp.MustCompile(`^[a-z0-9]{10}$`)},
+		{"{{$randomAlphaNumeric 4}}", regexp.MustCompile(`^[a-z0-9]{4}$`)},
+		{"{{$randomAlphaNumeric 0}}", regexp.MustCompile(`^$`)},
+		{"{{$base64 user:pass}}", regexp.MustCompile(`^dXNlcjpwYXNz$`)},
+		{"{{$base64Decode dXNlcjpwYXNz}}", regexp.MustCompile(`^user:pass$`)},
+		{"{{$urlEncode a b&c}}", regexp.MustCompile(`^a\+b%26c$`)},
+		{"id-{{ $randomInt 5 5 }}", regexp.MustCompile(`^id-5$`)},
+	}
+	for _, tt := range tests {
+		got, err := ExpandTemplate(tt.input)
+		if err != nil {
+			t.Errorf("ExpandTemplate(%q) error: %v", tt.input, err)
+			continue
+		}
+		if !tt.want.MatchString(got) {
+			t.Errorf("ExpandTemplate(%q) = %q, want match of %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRandomIntRange(t *testing.T) {
+	tests := []struct {
+		args     string
+		min, max int
+	}{
+		{"", 0, 1000},
+		{"10", 0, 10},
+		{"-5 5", -5, 5},
+		{"-9223372036854775806 0", -9223372036854775806, 0},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			value, err := randomInt(tt.args)
+			if err != nil {
+				t.Fatalf("randomInt(%q) error: %v", tt.args, err)
+			}
+			n, _ := strconv.Atoi(value)
+			if n < tt.min || n > tt.max {
+				t.Fatalf("randomInt(%q) = %d, want between %d and %d", tt.args, n, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestDynamicVarErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{"{{$nope}}", "unknown dynamic variable {{$nope}}"},
+		{"{{$randomInt 10 1}}", "max 1 is less than min 10"},
+		{"{{$randomInt -9223372036854775808 9223372036854775807}}", "is too large"},
+		{"{{$randomInt -1 9223372036854775807}}", "is too large"},
+		{"{{$randomInt -9223372036854775808 0}}", "is too large"},
+		{"{{$randomInt 1 2 3}}", "expected at most 2 arguments"},
+		{"{{$randomInt ten}}", "invalid syntax"},
+		{"{{$randomInt 99999999999999999999}}", "value out of range"},
+		{"{{$randomAlphaNumeric -1}}", "length should be between 0 and 10000, got -1"},
+		{"{{$randomAlphaNumeric 10001}}", "length should be between 0 and 10000, got 10001"},
+		{"{{$randomAlphaNumeric ten}}", "invalid syntax"},
+		{"{{$base64Decode not base64}}", "error evaluating {{$base64Decode}}"},
+	}
+	for _, tt := range tests {
+		_, err := ExpandTemplate(tt.input)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ExpandTemplate(%q) error = %v, want %q", tt.input, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDynamicVarsSeed(t *testing.T) {
+	expand := func() string {
+		SetSeed(42)
+		got, err := ExpandTemplate("{{$randomInt 1 1000}} {{$randomUUID}} {{$randomAlphaNumeric}}")
+		if err != nil {
+			t.Fatalf("error: %v", err)
+		}
+		return got
+	}
+	if first, second := expand(), expand(); first != second {
+		t.Errorf("values with the same seed differ: %q and %q", first, second)
+	}
+}
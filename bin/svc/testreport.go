@@ -0,0 +1,232 @@
+package svc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// TestReporter writes the results of restler test.
+type TestReporter interface {
+	Report(w io.Writer, results []TestResult, duration time.Duration) error
+}
+
+// DefaultTestReporter is the summary table.
+const DefaultTestReporter = "table"
+
+var testReporters = map[string]TestReporter{
+	"table": tableReporter{},
+	"junit": junitReporter{},
+	"tap":   tapReporter{},
+}
+
+// GetTestReporter returns the reporter by name, one of table, junit or tap.
+func GetTestReporter(name string) (TestReporter, error) {
+	if name == "" {
+		name = DefaultTestReporter
+	}
+	reporter, ok := testReporters[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(testReporters))
+		for name := range testReporters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown reporter %s, use one of %s", name, strings.Join(names, ", "))
+	}
+	return reporter, nil
+}
+
+// TestSummary counts the results.
+func TestSummary(results []TestResult) (passed int, failed int) {
+	for _, result := range results {
+		if result.Passed() {
+			passed++
+		} else {
+			failed++
+		}
+	}
+	return passed, failed
+}
+
+// tableReporter writes a row per request and the summary line.
+type tableReporter struct{}
+
+func (tableReporter) Report(w io.Writer, results []TestResult, duration time.Duration) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "RESULT\tREQUEST\tNAME\tMETHOD\tSTATUS\tTIME\tMESSAGE")
+	for _, result := range results {
+		status := "-"
+		if result.Status != 0 {
+			status = fmt.Sprint(result.Status)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%dms\t%s\n", result.Result(), result.File, result.Name,
+			result.Method, status, result.Duration.Milliseconds(), strings.ReplaceAll(result.Message(), "\n", " "))
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	passed, failed := TestSummary(results)
+	_, err := fmt.Fprintf(w, "\n%d passed, %d failed, %d total in %s\n", passed, failed, len(results), duration.Round(time.Millisecond))
+	return err
+}
+
+// tapReporter writes TAP version 13, failures have a yaml block with the
+// message and the failed assertions.
+type tapReporter struct{}
+
+func (tapReporter) Report(w io.Writer, results []TestResult, duration time.Duration) error {
+	var sb strings.Builder
+	sb.WriteString("TAP version 13\n")
+	sb.WriteString(fmt.Sprintf("1..%d\n", len(results)))
+	for i, result := range results {
+		description := result.File
+		if result.Name != "" {
+			description += " " + result.Name
+		}
+		description = strings.ReplaceAll(description, "#", "\\#")
+		if result.Passed() {
+			sb.WriteString(fmt.Sprintf("ok %d - %s\n", i+1, description))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("not ok %d - %s\n", i+1, description))
+		sb.WriteString("  ---\n")
+		sb.WriteString(fmt.Sprintf("  message: %q\n", result.Message()))
+		if result.Status != 0 {
+			sb.WriteString(fmt.Sprintf("  status: %d\n", result.Status))
+		}
+		sb.WriteString(fmt.Sprintf("  duration_ms: %g\n", milliseconds(result.Duration)))
+		if failures := failedAssertions(result); len(failures) > 0 {
+			sb.WriteString("  failures:\n")
+			for _, failure := range failures {
+				sb.WriteString(fmt.Sprintf("    - %q\n", failure))
+			}
+		}
+		sb.WriteString("  ...\n")
+	}
+	passed, failed := TestSummary(results)
+	sb.WriteString(fmt.Sprintf("# pass %d\n# fail %d\n", passed, failed))
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func failedAssertions(result TestResult) []string {
+	var failures []string
+	for _, assertion := range result.Assertions {
+		if !assertion.Passed {
+			failures = append(failures, fmt.Sprintf("%s: %s", assertion.Name, assertion.Message))
+		}
+	}
+	return failures
+}
+
+// junitReporter writes JUnit XML, a test suite per directory of the request
+// files and a test case per request.
+type junitReporter struct{}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Time     float64      `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Errors    int         `xml:"errors,attr"`
+	Time      float64     `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr,omitempty"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func (junitReporter) Report(w io.Writer, results []TestResult, duration time.Duration) error {
+	doc := junitSuites{Name: "restler", Time: duration.Seconds()}
+	suites := map[string]int{}
+	for _, result := range results {
+		dir := filepath.ToSlash(filepath.Dir(result.File))
+		index, ok := suites[dir]
+		if !ok {
+			index = len(doc.Suites)
+			suites[dir] = index
+			doc.Suites = append(doc.Suites, junitSuite{Name: dir, Timestamp: time.Now().Format(time.RFC3339)})
+		}
+		suite := &doc.Suites[index]
+
+		name := result.Name
+		if name == "" {
+			name = filepath.Base(result.File)
+		}
+		testCase := junitCase{
+			Name:      name,
+			ClassName: strings.TrimSuffix(filepath.ToSlash(result.File), filepath.Ext(result.File)),
+			File:      filepath.ToSlash(result.File),
+			Time:      result.Duration.Seconds(),
+		}
+		var assertions []string
+		for _, assertion := range result.Assertions {
+			line := fmt.Sprintf("%s %s", assertion.Result(), assertion.Name)
+			if assertion.Message != "" {
+				line += ": " + assertion.Message
+			}
+			assertions = append(assertions, line)
+		}
+		switch {
+		case result.Err != nil:
+			kind := string(ErrorKindOf(result.Err))
+			if kind == "" {
+				kind = "error"
+			}
+			testCase.Error = &junitFailure{Message: result.Message(), Type: kind, Text: result.Err.Error()}
+			suite.Errors++
+			doc.Errors++
+		case !result.Passed():
+			testCase.Failure = &junitFailure{Message: result.Message(), Type: string(ErrorKindAssertion), Text: strings.Join(failedAssertions(result), "\n")}
+			suite.Failures++
+			doc.Failures++
+		}
+		if len(assertions) > 0 {
+			testCase.SystemOut = strings.Join(assertions, "\n")
+		}
+		suite.Cases = append(suite.Cases, testCase)
+		suite.Tests++
+		suite.Time += result.Duration.Seconds()
+		doc.Tests++
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,267 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shrijan00003/restler/core/app"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultTestStatus is the expected status of the requests without Assert.Status.
+var DefaultTestStatus = StatusAssert{{From: 200, To: 299, raw: "2xx"}}
+
+// TestFilter selects the request files of restler test, globs without / match
+// the file name and globs with / match the path eg. users/**/*.get.yaml
+type TestFilter struct {
+	Include     []string
+	Exclude     []string
+	Tags        []string // request has any of the tags
+	ExcludeTags []string // request has none of the tags
+}
+
+// Filter returns the files selected by the filter in the same order.
+func (f TestFilter) Filter(files []string) ([]string, error) {
+	var selected []string
+	for _, file := range files {
+		ok, err := f.match(file)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			selected = append(selected, file)
+		}
+	}
+	return selected, nil
+}
+
+func (f TestFilter) match(file string) (bool, error) {
+	if len(f.Include) > 0 {
+		included, err := matchAnyGlob(f.Include, file)
+		if err != nil || !included {
+			return false, err
+		}
+	}
+	if excluded, err := matchAnyGlob(f.Exclude, file); err != nil || excluded {
+		return false, err
+	}
+	if len(f.Tags) == 0 && len(f.ExcludeTags) == 0 {
+		return true, nil
+	}
+
+	tags := readRequestInfo(file).Tags
+	if len(f.Tags) > 0 && !hasAnyTag(tags, f.Tags) {
+		return false, nil
+	}
+	return !hasAnyTag(tags, f.ExcludeTags), nil
+}
+
+// requestInfo reads name, method and tags of the request file without
+// expanding templates, so that filtered out requests don't need their
+// variables. Invalid files are reported when they run.
+type requestInfo struct {
+	Name   string   `yaml:"Name"`
+	Method string   `yaml:"Method"`
+	Tags   []string `yaml:"Tags"`
+}
+
+func readRequestInfo(file string) requestInfo {
+	var info requestInfo
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return info
+	}
+	yaml.Unmarshal(content, &info)
+	return info
+}
+
+func hasAnyTag(tags []string, filter []string) bool {
+	for _, tag := range filter {
+		if contains(tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAnyGlob(patterns []string, file string) (bool, error) {
+	for _, pattern := range patterns {
+		re, err := globRegexp(pattern)
+		if err != nil {
+			return false, Errorf(ErrorKindUsage, "invalid glob %q: %w", pattern, err)
+		}
+		name := strings.TrimPrefix(filepath.ToSlash(file), "./")
+		if !strings.Contains(pattern, "/") {
+			name = filepath.Base(file)
+		}
+		if re.MatchString(name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// globRegexp converts the glob to regexp, * doesn't match / while ** matches
+// any number of directories.
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// TestResult of a request file.
+type TestResult struct {
+	File       string
+	Name       string
+	Method     string
+	Status     int
+	Duration   time.Duration
+	Assertions AssertResults
+	// Err is the error of running the request, failed assertions are not Err
+	Err error
+}
+
+// Passed is true when the request is sent and all the assertions passed.
+func (r TestResult) Passed() bool {
+	return r.Err == nil && r.Assertions.Failed() == 0
+}
+
+// Result is PASS or FAIL.
+func (r TestResult) Result() string {
+	if r.Passed() {
+		return "PASS"
+	}
+	return "FAIL"
+}
+
+// Message is the error or the failed assertions of the result.
+func (r TestResult) Message() string {
+	// path of the file is already in the result
+	var restlerErr *Error
+	if errors.As(r.Err, &restlerErr) && restlerErr.Path == r.File && restlerErr.Line == 0 {
+		return restlerErr.Err.Error()
+	}
+	if r.Err != nil {
+		return r.Err.Error()
+	}
+	return strings.Join(failedAssertions(r), "; ")
+}
+
+// ExitCode of the result, failed assertions exit with ErrorKindAssertion.
+func (r TestResult) ExitCode() int {
+	if r.Err != nil {
+		return ExitCode(r.Err)
+	}
+	if !r.Passed() {
+		return exitCodes[ErrorKindAssertion]
+	}
+	return 0
+}
+
+// TestRequest runs the request file and checks the status against Assert.Status
+// of the request, 2xx is expected when it is not set.
+func TestRequest(file string, a *app.App, opts RunOptions) (*Response, TestResult) {
+	result := TestResult{File: file}
+	startedAt := time.Now()
+	res, err := RunRequest(file, a, opts)
+	result.Duration = time.Since(startedAt)
+	if err != nil {
+		info := readRequestInfo(file)
+		result.Name = info.Name
+		result.Method = info.Method
+		result.Err = err
+		return nil, result
+	}
+
+	req := res.Request
+	result.Name = req.Name
+	result.Method = req.Method
+	result.Status = res.HTTP.StatusCode
+	result.Duration = res.Timing.Total
+	if req.Assert == nil || len(req.Assert.Status) == 0 {
+		status := AssertResult{Name: fmt.Sprintf("Status is %s", DefaultTestStatus), Passed: DefaultTestStatus.Match(result.Status)}
+		if !status.Passed {
+			status.Message = fmt.Sprintf("got %d", result.Status)
+		}
+		res.Assertions = append(AssertResults{status}, res.Assertions...)
+	}
+	result.Assertions = res.Assertions
+	return res, result
+}
+
+// TestEnv applies After.Env of the tests one request at a time. Values are set
+// to the process env as well when the tests run one by one, so that the next
+// requests can use them. Parallel tests don't export them, otherwise a request
+// would see the env of whichever request finished before it, every request
+// sees the env of the start of the run instead.
+type TestEnv struct {
+	mu     sync.Mutex
+	export bool
+}
+
+// NewTestEnv returns the env of the tests, parallel is the number of the tests
+// running at the same time.
+func NewTestEnv(parallel int) *TestEnv {
+	return &TestEnv{export: parallel <= 1}
+}
+
+// Update runs update, which writes the env file, and exports the values it
+// returns when the tests run one by one.
+func (e *TestEnv) Update(update func() map[string]string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	values := update()
+	if !e.export {
+		return
+	}
+	for key, value := range values {
+		os.Setenv(key, value)
+	}
+}
+
+// RunTests runs test of every file, at most parallel tests run at the same
+// time. Results are in the order of the files.
+func RunTests(files []string, parallel int, test func(file string) TestResult) []TestResult {
+	results := make([]TestResult, len(files))
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, parallel)
+	for i, file := range files {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(i int, file string) {
+			defer wg.Done()
+			defer func() { <-limit }()
+			results[i] = test(file)
+		}(i, file)
+	}
+	wg.Wait()
+	return results
+}
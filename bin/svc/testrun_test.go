@@ -0,0 +1,170 @@
+package svc
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGlobRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.get.yaml", "posts.get.yaml", true},
+		{"*.get.yaml", "posts.post.yaml", false},
+		{"posts.?et.yaml", "posts.get.yaml", true},
+		{"users/*", "users/a.get.yaml", true},
+		{"users/*", "users/admin/a.get.yaml", false},
+		{"users/**", "users/admin/a.get.yaml", true},
+		{"**/a.get.yaml", "a.get.yaml", true},
+		{"**/a.get.yaml", "users/admin/a.get.yaml", true},
+		{"users/**/*.post.yaml", "users/b.post.yaml", true},
+		{"users/**/*.post.yaml", "users/admin/b.post.yaml", true},
+		{"users/**/*.post.yaml", "posts/b.post.yaml", false},
+		{"./users/*", "users/a.get.yaml", true},
+		{"a+b.yaml", "a+b.yaml", true},
+		{"a+b.yaml", "aab.yaml", false},
+	}
+	for _, tt := range tests {
+		re, err := globRegexp(tt.pattern)
+		if err != nil {
+			t.Errorf("globRegexp(%q) error: %v", tt.pattern, err)
+			continue
+		}
+		if got := re.MatchString(tt.name); got != tt.want {
+			t.Errorf("glob %q on %q = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFindRequestFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.yaml":                     "Env: default\n",
+		"env/default.yaml":                "API_URL: http://localhost\n",
+		"posts/posts.get.yaml":            "Name: Get\nURL: http://localhost\nMethod: GET\n",
+		"posts/data.yaml":                 "items: [1, 2]\n",
+		"posts/broken.yaml":               "Name: [broken\n",
+		"posts/.res.posts/.posts.get.yml": "Name: response\n",
+		"users/users.post.yml":            "Name: Post\nURL: http://localhost\nMethod: POST\n",
+		"users/notes.txt":                 "Name: not yaml\n",
+	})
+
+	files, err := FindRequestFiles([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "posts/broken.yaml"),
+		filepath.Join(dir, "posts/posts.get.yaml"),
+		filepath.Join(dir, "users/users.post.yml"),
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("FindRequestFiles = %v, want %v", files, want)
+	}
+
+	// files given directly are never skipped
+	data := filepath.Join(dir, "posts/data.yaml")
+	if files, err := FindRequestFiles([]string{data}); err != nil || len(files) != 1 {
+		t.Errorf("FindRequestFiles(%s) = %v, %v", data, files, err)
+	}
+}
+
+func TestTestFilter(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"users/a.get.yaml":       "Name: a\nTags: [smoke]\n",
+		"users/b.post.yaml":      "Name: b\nTags: [smoke, slow]\n",
+		"users/admin/c.get.yaml": "Name: c\n",
+		"posts/d.get.yaml":       "Name: d\nURL: '{{UNDEFINED}}'\nTags: [slow]\n",
+	})
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	files := []string{"posts/d.get.yaml", "users/a.get.yaml", "users/admin/c.get.yaml", "users/b.post.yaml"}
+	tests := []struct {
+		name   string
+		filter TestFilter
+		want   []string
+	}{
+		{"no filter", TestFilter{}, files},
+		{"include name", TestFilter{Include: []string{"*.get.yaml"}}, []string{"posts/d.get.yaml", "users/a.get.yaml", "users/admin/c.get.yaml"}},
+		{"include path", TestFilter{Include: []string{"users/*"}}, []string{"users/a.get.yaml", "users/b.post.yaml"}},
+		{"exclude", TestFilter{Include: []string{"users/**"}, Exclude: []string{"**/admin/*", "*.post.yaml"}}, []string{"users/a.get.yaml"}},
+		{"tag", TestFilter{Tags: []string{"smoke"}}, []string{"users/a.get.yaml", "users/b.post.yaml"}},
+		{"exclude tag", TestFilter{ExcludeTags: []string{"slow"}}, []string{"users/a.get.yaml", "users/admin/c.get.yaml"}},
+		{"tag and exclude tag", TestFilter{Tags: []string{"smoke"}, ExcludeTags: []string{"slow"}}, []string{"users/a.get.yaml"}},
+	}
+	for _, tt := range tests {
+		got, err := tt.filter.Filter(files)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Filter = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunTests(t *testing.T) {
+	files := []string{"a", "b", "c", "d", "e", "f"}
+	var running, maxRunning int32
+	results := RunTests(files, 3, func(file string) TestResult {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return TestResult{File: file}
+	})
+
+	if maxRunning > 3 {
+		t.Errorf("%d tests ran at the same time, want at most 3", maxRunning)
+	}
+	for i, result := range results {
+		if result.File != files[i] {
+			t.Errorf("result %d is of %s, want %s", i, result.File, files[i])
+		}
+	}
+}
+
+func TestTestEnv(t *testing.T) {
+	tests := []struct {
+		parallel int
+		want     string
+	}{
+		{parallel: 0, want: "updated"},
+		{parallel: 1, want: "updated"},
+		{parallel: 4, want: "initial"},
+	}
+	for _, tt := range tests {
+		t.Setenv("RESTLER_TEST_TOKEN", "initial")
+		calls := 0
+		NewTestEnv(tt.parallel).Update(func() map[string]string {
+			calls++
+			return map[string]string{"RESTLER_TEST_TOKEN": "updated"}
+		})
+		if calls != 1 {
+			t.Errorf("parallel %d: update called %d times, want 1", tt.parallel, calls)
+		}
+		if got := os.Getenv("RESTLER_TEST_TOKEN"); got != tt.want {
+			t.Errorf("parallel %d: env = %q, want %q", tt.parallel, got, tt.want)
+		}
+	}
+}
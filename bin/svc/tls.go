@@ -0,0 +1,132 @@
+package svc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/shrijan00003/restler/core/app"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// requestTLS merges tls options of the request with defaults from config,
+// paths from the request are resolved relative to the request file.
+func requestTLS(req *Request, a *app.App) app.TLS {
+	var options app.TLS
+	if a.Config != nil && a.Config.TLS != nil {
+		options = *a.Config.TLS
+	}
+	if req.TLS == nil {
+		return options
+	}
+
+	reqPath := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(req.Dir, path)
+	}
+
+	if req.TLS.CACert != "" {
+		options.CACert = reqPath(req.TLS.CACert)
+	}
+	// client certificate is taken as a whole, mixing cert from config and key
+	// from request doesn't make sense
+	if req.TLS.Cert != "" || req.TLS.PKCS12 != "" {
+		options.Cert = reqPath(req.TLS.Cert)
+		options.Key = reqPath(req.TLS.Key)
+		options.PKCS12 = reqPath(req.TLS.PKCS12)
+		options.PKCS12Password = req.TLS.PKCS12Password
+	}
+	if req.TLS.ServerName != "" {
+		options.ServerName = req.TLS.ServerName
+	}
+	if req.TLS.MinVersion != "" {
+		options.MinVersion = req.TLS.MinVersion
+	}
+	options.Insecure = options.Insecure || req.TLS.Insecure
+	return options
+}
+
+// redactedValue replaces the secrets of the request in the response files.
+const redactedValue = "[REDACTED]"
+
+// RedactRequest returns a copy of the request without the secrets, values of
+// the secrets are already expanded so they can't be written as they are.
+func RedactRequest(req *Request) *Request {
+	if req.TLS == nil || req.TLS.PKCS12Password == "" {
+		return req
+	}
+	redacted := *req
+	tlsOptions := *req.TLS
+	tlsOptions.PKCS12Password = redactedValue
+	redacted.TLS = &tlsOptions
+	return &redacted
+}
+
+func newTLSConfig(options app.TLS) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         options.ServerName,
+		InsecureSkipVerify: options.Insecure,
+	}
+
+	if options.MinVersion != "" {
+		version, ok := tlsVersions[options.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid TLS MinVersion %s, use one of 1.0, 1.1, 1.2, 1.3", options.MinVersion)
+		}
+		config.MinVersion = version
+	}
+
+	if options.CACert != "" {
+		pem, err := os.ReadFile(options.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA certificate %s", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid certificate found in CA certificate %s", options.CACert)
+		}
+		config.RootCAs = pool
+	}
+
+	switch {
+	case options.PKCS12 != "":
+		data, err := os.ReadFile(options.PKCS12)
+		if err != nil {
+			return nil, fmt.Errorf("error reading PKCS12 certificate %s", err)
+		}
+		key, cert, caCerts, err := pkcs12.DecodeChain(data, options.PKCS12Password)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding PKCS12 certificate %s", err)
+		}
+		clientCert := tls.Certificate{PrivateKey: key, Leaf: cert, Certificate: [][]byte{cert.Raw}}
+		for _, caCert := range caCerts {
+			clientCert.Certificate = append(clientCert.Certificate, caCert.Raw)
+		}
+		config.Certificates = []tls.Certificate{clientCert}
+	case options.Cert != "":
+		if options.Key == "" {
+			return nil, fmt.Errorf("TLS Key is required with Cert")
+		}
+		clientCert, err := tls.LoadX509KeyPair(options.Cert, options.Key)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate %s", err)
+		}
+		config.Certificates = []tls.Certificate{clientCert}
+	}
+
+	return config, nil
+}
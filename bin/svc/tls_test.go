@@ -0,0 +1,62 @@
+package svc
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shrijan00003/restler/core/app"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRedactRequest(t *testing.T) {
+	req := &Request{
+		URL:  "https://api.example.com/posts",
+		Body: map[string]interface{}{"title": "restler"},
+		TLS:  &app.TLS{PKCS12: "certs/client.p12", PKCS12Password: "hunter2"},
+	}
+	output, err := yaml.Marshal(RedactRequest(req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(output), "hunter2") {
+		t.Errorf("request has the PKCS#12 password:\n%s", output)
+	}
+	if !strings.Contains(string(output), "PKCS12Password: '[REDACTED]'") {
+		t.Errorf("request should show the password is redacted:\n%s", output)
+	}
+	if req.TLS.PKCS12Password != "hunter2" {
+		t.Errorf("request is changed, password = %q", req.TLS.PKCS12Password)
+	}
+
+	plain := &Request{URL: "https://api.example.com/posts"}
+	if RedactRequest(plain) != plain {
+		t.Errorf("request without secrets should be returned as it is")
+	}
+}
+
+func TestRequestTLS(t *testing.T) {
+	a := &app.App{Config: &app.Config{TLS: &app.TLS{
+		CACert:   "ca.pem",
+		Cert:     "config.pem",
+		Key:      "config.key",
+		Insecure: true,
+	}}}
+	req := &Request{Dir: "requests", TLS: &app.TLS{
+		PKCS12:         "client.p12",
+		PKCS12Password: "secret",
+		MinVersion:     "1.3",
+	}}
+
+	got := requestTLS(req, a)
+	want := app.TLS{
+		CACert:         "ca.pem",
+		PKCS12:         filepath.Join("requests", "client.p12"),
+		PKCS12Password: "secret",
+		MinVersion:     "1.3",
+		Insecure:       true,
+	}
+	if got != want {
+		t.Errorf("requestTLS = %+v, want %+v", got, want)
+	}
+}
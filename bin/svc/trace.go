@@ -0,0 +1,112 @@
+package svc
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/shrijan00003/restler/core/app"
+)
+
+// tracer records the request lifecycle timing with httptrace, timing is
+// complete only after the response body is read or closed.
+type tracer struct {
+	mu     sync.Mutex
+	timing *app.Timing
+	start  time.Time
+
+	dnsStart       time.Time
+	connectStart   time.Time
+	tlsStart       time.Time
+	firstByte      time.Time
+	transferRecord sync.Once
+}
+
+func newTracer(timing *app.Timing) *tracer {
+	*timing = app.Timing{}
+	return &tracer{timing: timing, start: time.Now()}
+}
+
+func (t *tracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.add(&t.timing.DNSLookup, t.dnsStart)
+		},
+		ConnectStart: func(string, string) { t.mark(&t.connectStart) },
+		ConnectDone: func(string, string, error) {
+			t.add(&t.timing.TCPConnect, t.connectStart)
+		},
+		TLSHandshakeStart: func() { t.mark(&t.tlsStart) },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.add(&t.timing.TLSHandshake, t.tlsStart)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.timing.ConnReused = info.Reused
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			t.firstByte = time.Now()
+			t.timing.TimeToFirstByte = t.firstByte.Sub(t.start)
+			t.mu.Unlock()
+		},
+	}
+}
+
+func (t *tracer) mark(at *time.Time) {
+	t.mu.Lock()
+	*at = time.Now()
+	t.mu.Unlock()
+}
+
+func (t *tracer) add(duration *time.Duration, since time.Time) {
+	t.mu.Lock()
+	*duration += time.Since(since)
+	t.mu.Unlock()
+}
+
+// done records content transfer and total time when the body is read.
+func (t *tracer) done() {
+	t.transferRecord.Do(func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		now := time.Now()
+		if !t.firstByte.IsZero() {
+			t.timing.ContentTransfer = now.Sub(t.firstByte)
+		}
+		t.timing.Total = now.Sub(t.start)
+	})
+}
+
+// traceRequest attaches the tracer to the request.
+func (t *tracer) traceRequest(req *http.Request) *http.Request {
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), t.clientTrace()))
+}
+
+// traceBody wraps the response body to know when the content transfer is done.
+func (t *tracer) traceBody(res *http.Response) {
+	res.Body = &tracedBody{ReadCloser: res.Body, tracer: t}
+}
+
+type tracedBody struct {
+	io.ReadCloser
+	tracer *tracer
+}
+
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.tracer.done()
+	}
+	return n, err
+}
+
+func (b *tracedBody) Close() error {
+	b.tracer.done()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,94 @@
+package svc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Transforms of the extracted value, jwt takes the claim as jwt:sub
+const (
+	TransformTrim         = "trim"
+	TransformBase64Decode = "base64-decode"
+	TransformJWT          = "jwt"
+)
+
+// transformSteps splits the transform like trim | jwt:sub into its steps
+func transformSteps(transform string) ([]string, error) {
+	steps := strings.Split(transform, "|")
+	for i, step := range steps {
+		steps[i] = strings.TrimSpace(step)
+		name, _, _ := strings.Cut(steps[i], ":")
+		switch name {
+		case TransformTrim, TransformBase64Decode, TransformJWT:
+		default:
+			return nil, fmt.Errorf("unknown Transform %q, use %s, %s or %s[:claim]", steps[i], TransformTrim, TransformBase64Decode, TransformJWT)
+		}
+	}
+	return steps, nil
+}
+
+// transform applies the steps of the transform in order
+func transform(transform string, value string) (string, error) {
+	steps, err := transformSteps(transform)
+	if err != nil {
+		return "", err
+	}
+	for _, step := range steps {
+		name, arg, _ := strings.Cut(step, ":")
+		switch name {
+		case TransformTrim:
+			value = strings.TrimSpace(value)
+		case TransformBase64Decode:
+			value, err = base64Decode(value)
+		case TransformJWT:
+			value, err = jwtClaim(value, arg)
+		}
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", step, err)
+		}
+	}
+	return value, nil
+}
+
+// base64Decode decodes standard and url encoding with or without padding
+func base64Decode(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	var err error
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		var decoded []byte
+		if decoded, err = encoding.DecodeString(value); err == nil {
+			return string(decoded), nil
+		}
+	}
+	return "", fmt.Errorf("value is not base64 encoded: %w", err)
+}
+
+// jwtClaim decodes payload of the token without verifying it, the whole
+// payload is returned as json when claim is empty. Bearer prefix is ignored
+// so that Authorization header can be used as it is.
+func jwtClaim(token string, claim string) (string, error) {
+	token = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(token), "Bearer "))
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("value is not a jwt")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return "", fmt.Errorf("jwt payload is not base64 encoded: %w", err)
+	}
+	if claim == "" {
+		return string(payload), nil
+	}
+
+	claims := map[string]interface{}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", fmt.Errorf("jwt payload is not in JSON format: %w", err)
+	}
+	value, ok := claims[claim]
+	if !ok || value == nil {
+		return "", fmt.Errorf("claim %s: %w", claim, ErrValueNotFound)
+	}
+	return formatValue(value), nil
+}
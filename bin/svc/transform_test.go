@@ -0,0 +1,67 @@
+package svc
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testJWT has {"sub":"user-1","admin":true,"exp":1700000000} as payload
+var testJWT = "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user-1","admin":true,"exp":1700000000}`)) + ".c2lnbmF0dXJl"
+
+func TestTransform(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform string
+		value     string
+		want      string
+	}{
+		{"trim", "trim", "  abc\n", "abc"},
+		{"base64", "base64-decode", "aGVsbG8/Pz8=", "hello???"},
+		{"base64 raw", "base64-decode", "aGVsbG8", "hello"},
+		{"base64 url", "base64-decode", "aGVsbG8_Pz8", "hello???"},
+		{"jwt payload", "jwt", testJWT, `{"sub":"user-1","admin":true,"exp":1700000000}`},
+		{"jwt claim", "jwt:sub", testJWT, "user-1"},
+		{"jwt bool claim", "jwt:admin", testJWT, "true"},
+		{"jwt number claim", "jwt:exp", testJWT, "1700000000"},
+		{"jwt bearer", "jwt:sub", "Bearer " + testJWT, "user-1"},
+		{"steps", " trim | base64-decode | trim ", " IGFiYyA= ", "abc"},
+	}
+	for _, tt := range tests {
+		got, err := transform(tt.transform, tt.value)
+		if err != nil {
+			t.Errorf("%s: error: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: transform(%q, %q) = %q, want %q", tt.name, tt.transform, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestTransformErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform string
+		value     string
+		wantErr   string
+	}{
+		{"unknown", "trim | upper", "a", `unknown Transform "upper"`},
+		{"not base64", "base64-decode", "not base64!", "base64-decode: value is not base64 encoded"},
+		{"not jwt", "jwt:sub", "abc", "jwt:sub: value is not a jwt"},
+		{"payload not json", "jwt:sub", "a." + base64.RawURLEncoding.EncodeToString([]byte("text")) + ".c", "jwt payload is not in JSON format"},
+		{"missing claim", "jwt:email", testJWT, "claim email: value not found"},
+	}
+	for _, tt := range tests {
+		_, err := transform(tt.transform, tt.value)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+
+	_, err := transform("jwt:email", testJWT)
+	if !errors.Is(err, ErrValueNotFound) {
+		t.Errorf("missing claim error = %v, want value not found", err)
+	}
+}
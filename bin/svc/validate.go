@@ -0,0 +1,316 @@
+package svc
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+	"gopkg.in/yaml.v3"
+)
+
+// Methods are the known http methods, other methods are validation error.
+var Methods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "TRACE", "CONNECT"}
+
+// requiredFields of the request file
+var requiredFields = []string{"Name", "URL", "Method"}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+var unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+// ErrorList has all the errors found in a request file.
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	messages := make([]string, len(l))
+	for i, err := range l {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (l ErrorList) Unwrap() []error {
+	errs := make([]error, len(l))
+	for i, err := range l {
+		errs[i] = err
+	}
+	return errs
+}
+
+func (l ErrorList) ExitCode() int {
+	return l[0].ExitCode()
+}
+
+// validator checks the request node against the schema of Request, it runs
+// after templates are expanded so that the final values are validated.
+type validator struct {
+	path string
+	errs ErrorList
+}
+
+// validateRequest returns ErrorList with line and column of every problem,
+// nil when the request is valid.
+func validateRequest(path string, node *yaml.Node) error {
+	v := &validator{path: path}
+	if node.Kind != yaml.MappingNode {
+		v.errorf(node, "request should be a yaml map")
+		return v.errs
+	}
+
+	v.checkType(node, reflect.TypeOf(Request{}), "")
+	fields := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		fields[node.Content[i].Value] = node.Content[i+1]
+	}
+	for _, name := range requiredFields {
+		if value, ok := fields[name]; !ok || (value.Kind == yaml.ScalarNode && (value.Value == "" || value.ShortTag() == "!!null")) {
+			v.errorf(node, "%s is required", name)
+		}
+	}
+	if method, ok := fields["Method"]; ok && method.Kind == yaml.ScalarNode && method.Value != "" {
+		v.checkMethod(method)
+	}
+	if u, ok := fields["URL"]; ok && u.Kind == yaml.ScalarNode && u.Value != "" {
+		v.checkURL(u)
+	}
+	if headers, ok := fields["Headers"]; ok && headers.Kind == yaml.MappingNode {
+		v.checkHeaders(headers)
+	}
+	if mode, ok := fields["BodyMode"]; ok && mode.Kind == yaml.ScalarNode && mode.Value != "" {
+		v.checkBodyMode(mode)
+	}
+
+	if len(v.errs) == 0 {
+		return nil
+	}
+	sort.SliceStable(v.errs, func(i, j int) bool {
+		if v.errs[i].Line != v.errs[j].Line {
+			return v.errs[i].Line < v.errs[j].Line
+		}
+		return v.errs[i].Column < v.errs[j].Column
+	})
+	return v.errs
+}
+
+func (v *validator) errorf(node *yaml.Node, format string, args ...interface{}) {
+	v.errs = append(v.errs, &Error{
+		Kind:   ErrorKindValidation,
+		Path:   v.path,
+		Line:   node.Line,
+		Column: node.Column,
+		Err:    fmt.Errorf(format, args...),
+	})
+}
+
+// checkType checks the node against the go type it is decoded to, field is
+// the dotted path of the node eg. Headers.Accept
+func (v *validator) checkType(node *yaml.Node, t reflect.Type, field string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null" {
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// types like Extractor and StatusAssert have their own forms eg. Body[token]
+	// or 2xx, they are checked by decoding after the fields of maps
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		errs := len(v.errs)
+		if t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode && hasFields(t) {
+			v.checkStruct(node, t, field)
+		}
+		if t.Kind() == reflect.Map && node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				v.checkType(node.Content[i+1], t.Elem(), joinField(field, node.Content[i].Value))
+			}
+		}
+		if len(v.errs) == errs {
+			if err := node.Decode(reflect.New(t).Interface()); err != nil {
+				v.errorf(node, "%s: %s", field, err)
+			}
+		}
+		return
+	}
+
+	if t == durationType {
+		if node.Kind != yaml.ScalarNode {
+			v.errorf(node, "%s should be a duration eg. 5s, got %s", field, nodeKind(node))
+		} else if node.ShortTag() == "!!int" && node.Value != "0" {
+			// yaml decodes numbers as nanoseconds, 5 would be 5ns
+			v.errorf(node, "%s should be a duration eg. 5s, got %s", field, nodeKind(node))
+		} else if _, err := time.ParseDuration(node.Value); err != nil {
+			v.errorf(node, "%s should be a duration eg. 5s, got %q", field, node.Value)
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			v.errorf(node, "%s should be a map, got %s", field, nodeKind(node))
+			return
+		}
+		v.checkStruct(node, t, field)
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			v.errorf(node, "%s should be a map, got %s", field, nodeKind(node))
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.checkType(node.Content[i+1], t.Elem(), joinField(field, node.Content[i].Value))
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			v.errorf(node, "%s should be a list, got %s", field, nodeKind(node))
+			return
+		}
+		for i, item := range node.Content {
+			v.checkType(item, t.Elem(), fmt.Sprintf("%s[%d]", field, i))
+		}
+	case reflect.String:
+		if node.Kind != yaml.ScalarNode {
+			v.errorf(node, "%s should be a string, got %s", field, nodeKind(node))
+		}
+	case reflect.Bool:
+		if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!bool" {
+			v.errorf(node, "%s should be true or false, got %s", field, nodeKind(node))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!int" {
+			v.errorf(node, "%s should be a whole number, got %s", field, nodeKind(node))
+		}
+	case reflect.Float32, reflect.Float64:
+		if node.Kind != yaml.ScalarNode || (node.ShortTag() != "!!int" && node.ShortTag() != "!!float") {
+			v.errorf(node, "%s should be a number, got %s", field, nodeKind(node))
+		}
+	}
+}
+
+// checkStruct checks fields of the map against the fields of the struct
+func (v *validator) checkStruct(node *yaml.Node, t reflect.Type, field string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		fieldType, ok := structField(t, key.Value)
+		if !ok {
+			v.errorf(key, "unknown field %s", joinField(field, key.Value))
+			continue
+		}
+		v.checkType(value, fieldType, joinField(field, key.Value))
+	}
+}
+
+func (v *validator) checkMethod(node *yaml.Node) {
+	for _, method := range Methods {
+		if node.Value == method {
+			return
+		}
+	}
+	for _, method := range Methods {
+		if strings.EqualFold(node.Value, method) {
+			v.errorf(node, "method %s should be upper case %s", node.Value, method)
+			return
+		}
+	}
+	v.errorf(node, "unknown method %s, use one of %s", node.Value, strings.Join(Methods, ", "))
+}
+
+func (v *validator) checkURL(node *yaml.Node) {
+	u, err := url.Parse(node.Value)
+	if err != nil {
+		v.errorf(node, "URL %q is not valid: %s", node.Value, err)
+		return
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		v.errorf(node, "URL %q should start with http:// or https://", node.Value)
+		return
+	}
+	if u.Host == "" {
+		v.errorf(node, "URL %q doesn't have host", node.Value)
+	}
+}
+
+func (v *validator) checkHeaders(node *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if !httpguts.ValidHeaderFieldName(key.Value) {
+			v.errorf(key, "invalid header name %q", key.Value)
+		}
+		if value.Kind == yaml.ScalarNode && !httpguts.ValidHeaderFieldValue(value.Value) {
+			v.errorf(value, "invalid value of header %s, header values can't have new lines or control characters", key.Value)
+		}
+	}
+}
+
+func (v *validator) checkBodyMode(node *yaml.Node) {
+	switch strings.ToLower(node.Value) {
+	case BodyModeJSON, BodyModeForm, BodyModeMultipart, BodyModeRaw, BodyModeFile, BodyModeBinary:
+	default:
+		v.errorf(node, "unsupported BodyMode %s, use one of json, form, multipart, raw, file, binary", node.Value)
+	}
+}
+
+// structField returns type of the field with the yaml name
+func structField(t reflect.Type, name string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, options, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+		if options == "inline" && field.Type.Kind() == reflect.Struct {
+			if fieldType, ok := structField(field.Type, name); ok {
+				return fieldType, true
+			}
+			continue
+		}
+		if tag == "" {
+			tag = strings.ToLower(field.Name)
+		}
+		if tag == name {
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
+
+// hasFields returns true when the struct has fields in the yaml, structs like
+// Schema without fields decode any map by themselves
+func hasFields(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.IsExported() && field.Tag.Get("yaml") != "-" {
+			return true
+		}
+	}
+	return false
+}
+
+func joinField(parent string, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// nodeKind describes the node for the error messages
+func nodeKind(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "map"
+	case yaml.SequenceNode:
+		return "list"
+	}
+	switch node.ShortTag() {
+	case "!!int", "!!float":
+		return "number " + node.Value
+	}
+	return strconv.Quote(node.Value)
+}
@@ -0,0 +1,139 @@
+package svc
+
+import (
+	"errors"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func validateYAML(t *testing.T, content string) error {
+	t.Helper()
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil {
+		t.Fatal(err)
+	}
+	return validateRequest("req.yaml", root.Content[0])
+}
+
+func TestValidateRequest(t *testing.T) {
+	valid := `
+Name: Get Posts
+URL: https://api.example.com/posts
+Method: GET
+Headers:
+  Accept: application/json
+  X-Count: 3
+Timeout:
+  Total: 5s
+  Connect: 0
+Retry:
+  MaxAttempts: 3
+  StatusCodes: [503]
+  NonIdempotent: true
+Redirect:
+  Follow: false
+Body: ~
+`
+	if err := validateYAML(t, valid); err != nil {
+		t.Errorf("valid request has errors:\n%v", err)
+	}
+}
+
+func TestValidateRequestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "required fields",
+			content: "Headers: {}\n",
+			want: []string{
+				"req.yaml:1:1: Name is required",
+				"req.yaml:1:1: URL is required",
+				"req.yaml:1:1: Method is required",
+			},
+		},
+		{
+			name: "method and url",
+			content: `Name: a
+URL: ftp://example.com
+Method: get
+`,
+			want: []string{
+				`req.yaml:2:6: URL "ftp://example.com" should start with http:// or https://`,
+				"req.yaml:3:9: method get should be upper case GET",
+			},
+		},
+		{
+			name: "duration as number",
+			content: `Name: a
+URL: http://example.com
+Method: GET
+Timeout: {Total: 5}
+`,
+			want: []string{"req.yaml:4:18: Timeout.Total should be a duration eg. 5s, got number 5"},
+		},
+		{
+			name: "assert timing",
+			content: `Name: a
+URL: http://example.com
+Method: GET
+Assert:
+  Timing:
+    ttfb: 200
+`,
+			want: []string{"req.yaml:6:11: Assert.Timing.ttfb should be a duration eg. 5s, got number 200"},
+		},
+		{
+			name: "wrong types",
+			content: `Name: a
+URL: http://example.com
+Method: GET
+Timeout:
+  Connect: soon
+  Total: [5s]
+Retry:
+  MaxAttempts: three
+  StatusCodes: 503
+  NonIdempotent: "yes"
+Redirect:
+  Max: 1.5
+Proxy: http://proxy:8080
+Header:
+  Accept: json
+`,
+			want: []string{
+				`req.yaml:5:12: Timeout.Connect should be a duration eg. 5s, got "soon"`,
+				"req.yaml:6:10: Timeout.Total should be a duration eg. 5s, got list",
+				"req.yaml:8:16: Retry.MaxAttempts should be a whole number, got \"three\"",
+				"req.yaml:9:16: Retry.StatusCodes should be a list, got number 503",
+				"req.yaml:10:18: Retry.NonIdempotent should be true or false, got \"yes\"",
+				"req.yaml:12:8: Redirect.Max should be a whole number, got number 1.5",
+				"req.yaml:13:8: Proxy should be a map, got \"http://proxy:8080\"",
+				"req.yaml:14:1: unknown field Header",
+			},
+		},
+	}
+	for _, tt := range tests {
+		err := validateYAML(t, tt.content)
+		var errs ErrorList
+		if !errors.As(err, &errs) {
+			t.Errorf("%s: error = %v, want ErrorList", tt.name, err)
+			continue
+		}
+		if len(errs) != len(tt.want) {
+			t.Errorf("%s: errors =\n%v\nwant\n%v", tt.name, err, tt.want)
+			continue
+		}
+		for i, want := range tt.want {
+			if errs[i].Error() != want {
+				t.Errorf("%s: error %d = %q, want %q", tt.name, i, errs[i].Error(), want)
+			}
+			if errs[i].Kind != ErrorKindValidation {
+				t.Errorf("%s: kind = %q, want validation", tt.name, errs[i].Kind)
+			}
+		}
+	}
+}
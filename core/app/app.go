@@ -1,24 +1,41 @@
 package app
 
-import "time"
+import (
+	"net/http"
+	"time"
+)
 
 type Config struct {
-	Env     string `yaml:"Env"`
-	EnvPath string `yaml:"EnvPath"`
+	Env     string   `yaml:"Env"`
+	EnvPath string   `yaml:"EnvPath"`
+	Timeout *Timeout `yaml:"Timeout"`
+	Retry   *Retry   `yaml:"Retry"`
+	TLS     *TLS     `yaml:"TLS"`
+	// Proxies are per host proxy rules, they have precedence over proxies
+	// from env.
+	Proxies []ProxyRule `yaml:"Proxies"`
+	// CookieJar persists cookies of the responses per env in .restler folder
+	// and sends them with the next requests.
+	CookieJar bool `yaml:"CookieJar"`
+	// Formats of the response files eg. [markdown, har], see docs/format.md
+	Formats []string `yaml:"Formats"`
 }
 
 type App struct {
-	ProxyUrl    string
 	Version     string
 	Config      *Config
 	RequestTime time.Duration
+	Timing      Timing
+	Attempts    []Attempt
+	// Jar is shared by the requests of the app when it is set, otherwise every
+	// request loads the cookie jar of the env.
+	Jar http.CookieJar
 }
 
-func NewApp(proxyUrl string, version string, config *Config) *App {
+func NewApp(version string, config *Config) *App {
 	return &App{
-		ProxyUrl: proxyUrl,
-		Version:  version,
-		Config:   config,
+		Version: version,
+		Config:  config,
 	}
 }
 
@@ -36,14 +36,14 @@ func load(config *app.Config) {
 	for _, pattern := range envFilePatterns {
 		matchedFiles, err := filepath.Glob(os.ExpandEnv(pattern))
 		if err != nil {
-			fmt.Println("[Restler Log]: Error loading .env file: ", err)
+			fmt.Fprintln(os.Stderr, "[Restler Log]: Error loading .env file: ", err)
 		}
 		envFiles = append(envFiles, matchedFiles...)
 	}
 
 	err := godotenv.Load(envFiles...)
 	if err != nil {
-		fmt.Println("[restler info]: Error loading .env file: ", err)
+		fmt.Fprintln(os.Stderr, "[restler info]: Error loading .env file: ", err)
 	}
 
 }
@@ -98,9 +98,9 @@ func GetEnv(key string) string {
 func UpdateEnv(path string) error {
 	dotEnvPath, err := findDotEnvFile()
 	if err != nil {
-		fmt.Println("[log]: env file .env or .env.local not found, creating .env file :")
+		fmt.Fprintln(os.Stderr, "[log]: env file .env or .env.local not found, creating .env file :")
 		if _, err := os.Create(".env"); err != nil {
-			fmt.Println("[error]: Error occurred while creating .env file: ", err)
+			fmt.Fprintln(os.Stderr, "[error]: Error occurred while creating .env file: ", err)
 			return err
 		}
 		dotEnvPath = ".env"
@@ -112,13 +112,13 @@ func UpdateEnv(path string) error {
 func UpdateEnvFile(a *app.App, values map[string]interface{}) error {
 	envPath := GetCurrentEnvPath(a)
 	if envPath == "" {
-		fmt.Println("[error]: Env file path not found")
+		fmt.Fprintln(os.Stderr, "[error]: Env file path not found")
 		return fmt.Errorf("env file path not found")
 	}
 
 	file, err := os.Open(envPath)
 	if err != nil {
-		fmt.Println("[error]: Error occurred while opening .env file: ", err)
+		fmt.Fprintln(os.Stderr, "[error]: Error occurred while opening .env file: ", err)
 		return err
 	}
 	defer file.Close()
@@ -147,7 +147,7 @@ func UpdateEnvFile(a *app.App, values map[string]interface{}) error {
 
 	err = os.WriteFile(envPath, []byte(strings.Join(lines, "\n")), 0644)
 	if err != nil {
-		fmt.Printf("[error]: Error occurred while updating %s file: %s\n", envPath, err)
+		fmt.Fprintf(os.Stderr, "[error]: Error occurred while updating %s file: %s\n", envPath, err)
 		return err
 	}
 	return nil
@@ -156,7 +156,7 @@ func UpdateEnvFile(a *app.App, values map[string]interface{}) error {
 func updateDotEnvFile(envPath, restlerPath string) error {
 	file, err := os.Open(envPath)
 	if err != nil {
-		fmt.Println("[error]: Error occurred while opening .env file: ", err)
+		fmt.Fprintln(os.Stderr, "[error]: Error occurred while opening .env file: ", err)
 		return err
 	}
 	defer file.Close()
@@ -180,13 +180,13 @@ func updateDotEnvFile(envPath, restlerPath string) error {
 	}
 
 	if err := scanner.Err(); err != nil {
-		fmt.Printf("[error]: Error occurred while reading %s file: %s\n", envPath, err)
+		fmt.Fprintf(os.Stderr, "[error]: Error occurred while reading %s file: %s\n", envPath, err)
 		return err
 	}
 
 	err = os.WriteFile(envPath, []byte(strings.Join(lines, "\n")), 0644)
 	if err != nil {
-		fmt.Printf("[error]: Error occurred while updating %s file: %s\n", envPath, err)
+		fmt.Fprintf(os.Stderr, "[error]: Error occurred while updating %s file: %s\n", envPath, err)
 		return err
 	}
 	return nil
@@ -10,7 +10,7 @@ var logLevel = slog.LevelInfo
 var logger *slog.Logger
 
 func Init() {
-	logger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: &logLevel}))
+	logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: &logLevel}))
 }
 
 func Terminate() {
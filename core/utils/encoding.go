@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// AcceptEncoding is sent when request doesn't have its own Accept-Encoding.
+const AcceptEncoding = "gzip, deflate, br, zstd"
+
+// ContentEncodings returns the content encodings of the response in the order
+// they are applied, eg. "gzip, br" is gzip first and then br.
+func ContentEncodings(header http.Header) []string {
+	var encodings []string
+	for _, value := range header.Values("Content-Encoding") {
+		for _, encoding := range strings.Split(value, ",") {
+			encoding = strings.ToLower(strings.TrimSpace(encoding))
+			if encoding != "" && encoding != "identity" {
+				encodings = append(encodings, encoding)
+			}
+		}
+	}
+	return encodings
+}
+
+// ErrUnsupportedEncoding is returned by DecodeBody with the raw body when one
+// of the encodings is not supported.
+var ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// HasBody is false for the responses which don't have a body even when they
+// have Content-Encoding, ie. responses of HEAD requests, 204 and 304.
+func HasBody(res *http.Response) bool {
+	if res.Request != nil && res.Request.Method == http.MethodHead {
+		return false
+	}
+	return res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusNotModified
+}
+
+// DecodeBody decodes the raw body with the encodings, encodings are removed in
+// the reverse order they are applied. Empty body is returned as it is.
+func DecodeBody(raw []byte, encodings []string) ([]byte, error) {
+	if len(raw) == 0 {
+		return raw, nil
+	}
+	for _, encoding := range encodings {
+		if !supportedEncoding(encoding) {
+			return raw, fmt.Errorf("%w : %s", ErrUnsupportedEncoding, encoding)
+		}
+	}
+
+	body := raw
+	for i := len(encodings) - 1; i >= 0; i-- {
+		var err error
+		body, err = decode(body, encodings[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+func supportedEncoding(encoding string) bool {
+	switch encoding {
+	case "gzip", "x-gzip", "deflate", "br", "zstd":
+		return true
+	}
+	return false
+}
+
+func decode(body []byte, encoding string) ([]byte, error) {
+	var reader io.Reader
+	switch encoding {
+	case "gzip", "x-gzip":
+		gzipReader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("error creating gzip reader : %v", err)
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	case "deflate":
+		// deflate should be zlib wrapped, but some servers send raw deflate
+		zlibReader, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			reader = flate.NewReader(bytes.NewReader(body))
+		} else {
+			defer zlibReader.Close()
+			reader = zlibReader
+		}
+	case "br":
+		reader = brotli.NewReader(bytes.NewReader(body))
+	case "zstd":
+		zstdReader, err := zstd.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("error creating zstd reader : %v", err)
+		}
+		defer zstdReader.Close()
+		reader = zstdReader
+	default:
+		return nil, fmt.Errorf("%w : %s", ErrUnsupportedEncoding, encoding)
+	}
+
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s response body : %v", encoding, err)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func compress(t *testing.T, encoding string, body []byte) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	var writer io.WriteCloser
+	switch encoding {
+	case "gzip":
+		writer = gzip.NewWriter(&buffer)
+	case "deflate":
+		writer = zlib.NewWriter(&buffer)
+	case "raw-deflate":
+		writer, _ = flate.NewWriter(&buffer, flate.DefaultCompression)
+	case "br":
+		writer = brotli.NewWriter(&buffer)
+	case "zstd":
+		var err error
+		writer, err = zstd.NewWriter(&buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+	default:
+		t.Fatalf("unknown encoding %s", encoding)
+	}
+	writer.Write(body)
+	writer.Close()
+	return buffer.Bytes()
+}
+
+func TestContentEncodings(t *testing.T) {
+	header := http.Header{}
+	header.Add("Content-Encoding", "GZIP, identity")
+	header.Add("Content-Encoding", " br ")
+	got := ContentEncodings(header)
+	if len(got) != 2 || got[0] != "gzip" || got[1] != "br" {
+		t.Errorf("ContentEncodings = %v, want [gzip br]", got)
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	body := []byte(`{"id": 1, "title": "restler"}`)
+	tests := []struct {
+		name      string
+		raw       []byte
+		encodings []string
+	}{
+		{"none", body, nil},
+		{"gzip", compress(t, "gzip", body), []string{"gzip"}},
+		{"x-gzip", compress(t, "gzip", body), []string{"x-gzip"}},
+		{"deflate", compress(t, "deflate", body), []string{"deflate"}},
+		{"raw deflate", compress(t, "raw-deflate", body), []string{"deflate"}},
+		{"br", compress(t, "br", body), []string{"br"}},
+		{"zstd", compress(t, "zstd", body), []string{"zstd"}},
+		{"stacked", compress(t, "br", compress(t, "gzip", body)), []string{"gzip", "br"}},
+	}
+	for _, tt := range tests {
+		got, err := DecodeBody(tt.raw, tt.encodings)
+		if err != nil {
+			t.Errorf("%s: DecodeBody error: %v", tt.name, err)
+			continue
+		}
+		if !bytes.Equal(got, body) {
+			t.Errorf("%s: DecodeBody = %q, want %q", tt.name, got, body)
+		}
+	}
+}
+
+func TestDecodeBodyEmpty(t *testing.T) {
+	for _, encoding := range []string{"gzip", "deflate", "br", "zstd", "unknown"} {
+		got, err := DecodeBody(nil, []string{encoding})
+		if err != nil || len(got) != 0 {
+			t.Errorf("DecodeBody of empty %s body = %q, %v, want empty body", encoding, got, err)
+		}
+	}
+}
+
+func TestDecodeBodyErrors(t *testing.T) {
+	raw := []byte("plain text")
+	got, err := DecodeBody(raw, []string{"gzip", "compress"})
+	if !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("unsupported encoding error = %v, want ErrUnsupportedEncoding", err)
+	}
+	if !bytes.Equal(got, raw) {
+		t.Errorf("unsupported encoding body = %q, want the raw body", got)
+	}
+
+	if _, err := DecodeBody(raw, []string{"gzip"}); err == nil || errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("invalid gzip body error = %v, want decoding error", err)
+	}
+}
+
+func TestHasBody(t *testing.T) {
+	tests := []struct {
+		method string
+		status int
+		want   bool
+	}{
+		{http.MethodGet, http.StatusOK, true},
+		{http.MethodHead, http.StatusOK, false},
+		{http.MethodGet, http.StatusNoContent, false},
+		{http.MethodGet, http.StatusNotModified, false},
+		{http.MethodPost, http.StatusCreated, true},
+	}
+	for _, tt := range tests {
+		res := &http.Response{StatusCode: tt.status, Request: &http.Request{Method: tt.method}}
+		if got := HasBody(res); got != tt.want {
+			t.Errorf("HasBody(%s %d) = %v, want %v", tt.method, tt.status, got, tt.want)
+		}
+	}
+}
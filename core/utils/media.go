@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+// Media of the response body detected from Content-Type header and the body.
+type Media struct {
+	MediaType string
+	Charset   string
+	Binary    bool
+}
+
+// DetectMedia detects media type and charset of the body, Content-Type header
+// is preferred and the body is sniffed when header is missing.
+func DetectMedia(header http.Header, body []byte) Media {
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+
+	media := Media{MediaType: mediaType, Charset: strings.ToLower(params["charset"])}
+	if !IsTextMedia(mediaType) {
+		// other types are text only if the content looks like utf-8 text
+		looksText := utf8.Valid(body) && strings.HasPrefix(http.DetectContentType(body), "text/")
+		media.Binary = len(body) > 0 && !looksText
+	}
+	return media
+}
+
+// IsTextMedia returns true for text media types like text/*, json and xml.
+func IsTextMedia(mediaType string) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"),
+		strings.HasSuffix(mediaType, "json"),
+		strings.HasSuffix(mediaType, "/xml"),
+		strings.HasSuffix(mediaType, "/yaml"),
+		strings.HasSuffix(mediaType, "/x-yaml"),
+		strings.HasSuffix(mediaType, "/javascript"),
+		strings.HasSuffix(mediaType, "/graphql"),
+		mediaType == "application/x-www-form-urlencoded":
+		return true
+	}
+	return false
+}
+
+// ToUTF8 transcodes text body to utf-8 with its charset, html without charset
+// in the header is checked for <meta charset>.
+func ToUTF8(body []byte, media Media) ([]byte, error) {
+	if media.Binary {
+		return body, nil
+	}
+	if media.Charset == "" && media.MediaType != "text/html" {
+		return body, nil
+	}
+
+	contentType := media.MediaType
+	if media.Charset != "" {
+		contentType += "; charset=" + media.Charset
+	}
+	encoding, name, _ := charset.DetermineEncoding(body, contentType)
+	if name == "utf-8" {
+		return body, nil
+	}
+	return io.ReadAll(encoding.NewDecoder().Reader(bytes.NewReader(body)))
+}
+
+// CodeFence returns language of the markdown code block for the media type.
+func CodeFence(mediaType string) string {
+	switch {
+	case strings.HasSuffix(mediaType, "json"):
+		return "json"
+	case strings.HasSuffix(mediaType, "xml"):
+		return "xml"
+	case mediaType == "text/html":
+		return "html"
+	case strings.HasSuffix(mediaType, "yaml"):
+		return "yaml"
+	case strings.HasSuffix(mediaType, "javascript"):
+		return "javascript"
+	case mediaType == "text/css":
+		return "css"
+	default:
+		return "text"
+	}
+}
+
+// FileExtension returns extension for saving body of the media type.
+func FileExtension(mediaType string) string {
+	known := map[string]string{
+		"image/jpeg":      ".jpg",
+		"image/png":       ".png",
+		"image/gif":       ".gif",
+		"image/webp":      ".webp",
+		"image/svg+xml":   ".svg",
+		"application/pdf": ".pdf",
+		"application/zip": ".zip",
+	}
+	if ext, ok := known[mediaType]; ok {
+		return ext
+	}
+	if extensions, err := mime.ExtensionsByType(mediaType); err == nil && len(extensions) > 0 {
+		return extensions[0]
+	}
+	return ".bin"
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDetectMedia(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        Media
+	}{
+		{"json", "application/json; charset=UTF-8", []byte(`{"a":1}`), Media{MediaType: "application/json", Charset: "utf-8"}},
+		{"problem json", "application/problem+json", []byte(`{}`), Media{MediaType: "application/problem+json"}},
+		{"latin1 text", "text/plain; charset=ISO-8859-1", []byte("caf\xe9"), Media{MediaType: "text/plain", Charset: "iso-8859-1"}},
+		{"png", "image/png", []byte("\x89PNG\r\n\x1a\n\x00\x00"), Media{MediaType: "image/png", Binary: true}},
+		{"text as octet stream", "application/octet-stream", []byte("plain text"), Media{MediaType: "application/octet-stream"}},
+		{"sniffed html", "", []byte("<html><body>hi</body></html>"), Media{MediaType: "text/html", Charset: "utf-8"}},
+		{"sniffed binary", "", []byte("\x00\x01\x02\xff"), Media{MediaType: "application/octet-stream", Binary: true}},
+		{"invalid header", "text/plain; charset", []byte("hi"), Media{MediaType: "text/plain"}},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.contentType != "" {
+			header.Set("Content-Type", tt.contentType)
+		}
+		if got := DetectMedia(header, tt.body); got != tt.want {
+			t.Errorf("%s: DetectMedia = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestToUTF8(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		media Media
+		want  string
+	}{
+		{"latin1", "caf\xe9", Media{MediaType: "text/plain", Charset: "iso-8859-1"}, "café"},
+		{"windows-1252", "\x93quoted\x94", Media{MediaType: "text/plain", Charset: "windows-1252"}, "“quoted”"},
+		{"shift_jis", "\x93\xfa\x96\x7b", Media{MediaType: "text/plain", Charset: "shift_jis"}, "日本"},
+		{"utf-8", "café", Media{MediaType: "application/json", Charset: "utf-8"}, "café"},
+		{"no charset", "caf\xe9", Media{MediaType: "application/json"}, "caf\xe9"},
+		{"html meta charset", `<html><head><meta charset="iso-8859-1"></head><body>caf` + "\xe9</body></html>", Media{MediaType: "text/html"}, `<html><head><meta charset="iso-8859-1"></head><body>café</body></html>`},
+		{"binary", "\xe9\x00", Media{MediaType: "image/png", Charset: "iso-8859-1", Binary: true}, "\xe9\x00"},
+	}
+	for _, tt := range tests {
+		got, err := ToUTF8([]byte(tt.body), tt.media)
+		if err != nil {
+			t.Errorf("%s: ToUTF8 error: %v", tt.name, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: ToUTF8 = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFileExtension(t *testing.T) {
+	tests := map[string]string{
+		"image/png":                ".png",
+		"image/jpeg":               ".jpg",
+		"application/pdf":          ".pdf",
+		"application/x-unknown-42": ".bin",
+	}
+	for mediaType, want := range tests {
+		if got := FileExtension(mediaType); got != want {
+			t.Errorf("FileExtension(%q) = %q, want %q", mediaType, got, want)
+		}
+	}
+}
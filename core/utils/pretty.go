@@ -0,0 +1,309 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// PrettyOptions controls how response bodies are pretty printed.
+type PrettyOptions struct {
+	// SortKeys sorts keys of json objects, keys are kept in the order sent by
+	// the server by default.
+	SortKeys bool
+}
+
+// PrettyBody pretty prints json, ndjson, xml and html bodies, body is returned
+// as it is for other media types or when it can't be parsed.
+func PrettyBody(body []byte, mediaType string, opts PrettyOptions) []byte {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return body
+	}
+
+	var pretty []byte
+	var err error
+	switch {
+	case IsJSONLinesMedia(mediaType):
+		pretty, err = PrettyJSONLines(body, opts)
+	case strings.HasSuffix(mediaType, "json"):
+		pretty, err = PrettyJSON(body, opts)
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		pretty, err = PrettyHTML(body)
+	case strings.HasSuffix(mediaType, "xml"):
+		pretty, err = PrettyXML(body)
+	default:
+		return body
+	}
+	if err != nil {
+		return body
+	}
+	return pretty
+}
+
+// IsJSONLinesMedia returns true for newline delimited json media types.
+func IsJSONLinesMedia(mediaType string) bool {
+	switch mediaType {
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines", "application/jsonlines":
+		return true
+	}
+	return false
+}
+
+// +++++++++++++++++++++++++++++++++++++++++++++
+// json
+// +++++++++++++++++++++++++++++++++++++++++++++
+func PrettyJSON(body []byte, opts PrettyOptions) ([]byte, error) {
+	if !opts.SortKeys {
+		// json.Indent keeps the order of the keys and the numbers as it is
+		var buffer bytes.Buffer
+		if err := json.Indent(&buffer, bytes.TrimSpace(body), "", "  "); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), nil
+	}
+
+	value, err := decodeJSON(body)
+	if err != nil {
+		return nil, err
+	}
+	return encodeJSON(value, "  ")
+}
+
+// PrettyJSONLines renders every record of ndjson body in a single line with
+// consistent spacing, blank lines are dropped.
+func PrettyJSONLines(body []byte, opts PrettyOptions) ([]byte, error) {
+	var buffer bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), len(body)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record []byte
+		if opts.SortKeys {
+			value, err := decodeJSON(line)
+			if err != nil {
+				return nil, err
+			}
+			if record, err = encodeJSON(value, ""); err != nil {
+				return nil, err
+			}
+		} else {
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, line); err != nil {
+				return nil, err
+			}
+			record = compact.Bytes()
+		}
+		if buffer.Len() > 0 {
+			buffer.WriteByte('\n')
+		}
+		buffer.Write(record)
+	}
+	return buffer.Bytes(), scanner.Err()
+}
+
+// decodeJSON decodes single json value keeping numbers as they are sent.
+func decodeJSON(body []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after json value")
+	}
+	return value, nil
+}
+
+// encodeJSON encodes value with sorted keys, html characters are not escaped.
+func encodeJSON(value interface{}, indent string) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if indent != "" {
+		encoder.SetIndent("", indent)
+	}
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+// +++++++++++++++++++++++++++++++++++++++++++++
+// xml
+// +++++++++++++++++++++++++++++++++++++++++++++
+func PrettyXML(body []byte) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.Strict = false
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		// body is already transcoded to utf-8
+		return input, nil
+	}
+
+	var buffer bytes.Buffer
+	encoder := xml.NewEncoder(&buffer)
+	// encoder's own indentation skips comments and processing instructions,
+	// so new lines are written here, text is kept inline with its element
+	depth := 0
+	var prev xml.Token
+	newLine := func(depth int) {
+		encoder.Flush()
+		if buffer.Len() > 0 {
+			buffer.WriteString("\n" + strings.Repeat("  ", depth))
+		}
+	}
+	for {
+		// raw tokens keep the namespace prefixes as they are written
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			text := bytes.TrimSpace(t)
+			if len(text) == 0 {
+				continue
+			}
+			token = xml.CharData(text)
+		case xml.StartElement:
+			newLine(depth)
+			depth++
+			t.Name = prefixedName(t.Name)
+			attrs := make([]xml.Attr, len(t.Attr))
+			for i, attr := range t.Attr {
+				attrs[i] = xml.Attr{Name: prefixedName(attr.Name), Value: attr.Value}
+			}
+			t.Attr = attrs
+			token = t
+		case xml.EndElement:
+			depth--
+			switch prev.(type) {
+			case xml.StartElement, xml.CharData:
+			default:
+				newLine(depth)
+			}
+			t.Name = prefixedName(t.Name)
+			token = t
+		default:
+			newLine(depth)
+		}
+		if err := encoder.EncodeToken(token); err != nil {
+			return nil, err
+		}
+		prev = token
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// prefixedName moves the prefix into the local name, so that the encoder
+// writes it as it is instead of generating its own namespace declarations.
+func prefixedName(name xml.Name) xml.Name {
+	if name.Space == "" {
+		return name
+	}
+	return xml.Name{Local: name.Space + ":" + name.Local}
+}
+
+// +++++++++++++++++++++++++++++++++++++++++++++
+// html
+// +++++++++++++++++++++++++++++++++++++++++++++
+func PrettyHTML(body []byte) ([]byte, error) {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	if err := writeHTMLNode(&buffer, doc, 0); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+// elements with whitespace sensitive or raw content are written as they are
+var preformattedElements = map[string]bool{"pre": true, "textarea": true, "script": true, "style": true}
+
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+func writeHTMLNode(buffer *bytes.Buffer, node *html.Node, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	switch node.Type {
+	case html.DocumentNode:
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if err := writeHTMLNode(buffer, child, depth); err != nil {
+				return err
+			}
+		}
+		return nil
+	case html.TextNode:
+		text := strings.Join(strings.Fields(node.Data), " ")
+		if text == "" {
+			return nil
+		}
+		buffer.WriteString(indent + html.EscapeString(text) + "\n")
+		return nil
+	case html.ElementNode:
+		if !preformattedElements[node.Data] && !hasOnlyText(node) {
+			buffer.WriteString(indent)
+			writeStartTag(buffer, node)
+			buffer.WriteString("\n")
+			if voidElements[node.Data] {
+				return nil
+			}
+			for child := node.FirstChild; child != nil; child = child.NextSibling {
+				if err := writeHTMLNode(buffer, child, depth+1); err != nil {
+					return err
+				}
+			}
+			buffer.WriteString(indent + "</" + node.Data + ">\n")
+			return nil
+		}
+	}
+
+	// doctype, comments, preformatted elements and elements with only text
+	buffer.WriteString(indent)
+	if err := html.Render(buffer, node); err != nil {
+		return err
+	}
+	buffer.WriteString("\n")
+	return nil
+}
+
+func writeStartTag(buffer *bytes.Buffer, node *html.Node) {
+	buffer.WriteString("<" + node.Data)
+	for _, attr := range node.Attr {
+		name := attr.Key
+		if attr.Namespace != "" {
+			name = attr.Namespace + ":" + attr.Key
+		}
+		buffer.WriteString(" " + name + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+	buffer.WriteString(">")
+}
+
+// hasOnlyText returns true for elements with a single short text child eg.
+// <title>, <a>, <li>, they are kept in one line.
+func hasOnlyText(node *html.Node) bool {
+	child := node.FirstChild
+	if child == nil {
+		return !voidElements[node.Data]
+	}
+	return child.NextSibling == nil && child.Type == html.TextNode && !strings.Contains(strings.TrimSpace(child.Data), "\n") && len(child.Data) <= 80
+}
@@ -0,0 +1,100 @@
+package utils
+
+import "testing"
+
+func TestPrettyBody(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		mediaType string
+		opts      PrettyOptions
+		want      string
+	}{
+		{
+			name:      "json keeps order and numbers",
+			body:      `{"b":1,"a":{"d":[1,2.50],"c":"<x>"}}`,
+			mediaType: "application/json",
+			want: `{
+  "b": 1,
+  "a": {
+    "d": [
+      1,
+      2.50
+    ],
+    "c": "<x>"
+  }
+}`,
+		},
+		{
+			name:      "json with sorted keys",
+			body:      `{"b":1,"a":{"d":[1,2.50],"c":"<x>"}}`,
+			mediaType: "application/problem+json",
+			opts:      PrettyOptions{SortKeys: true},
+			want: `{
+  "a": {
+    "c": "<x>",
+    "d": [
+      1,
+      2.50
+    ]
+  },
+  "b": 1
+}`,
+		},
+		{
+			name:      "ndjson",
+			body:      "{\"b\": 1, \"a\": 2}\n\n{\"c\":3}\n",
+			mediaType: "application/x-ndjson",
+			want:      "{\"b\":1,\"a\":2}\n{\"c\":3}",
+		},
+		{
+			name:      "ndjson with sorted keys",
+			body:      "{\"b\": 1, \"a\": 2}\n{\"c\":3}",
+			mediaType: "application/x-ndjson",
+			opts:      PrettyOptions{SortKeys: true},
+			want:      "{\"a\":2,\"b\":1}\n{\"c\":3}",
+		},
+		{
+			name:      "xml keeps prefixes and comments",
+			body:      `<?xml version="1.0"?><!-- c --><soap:Envelope xmlns:soap="http://x"><soap:Body><a id="1">text</a><b/></soap:Body></soap:Envelope>`,
+			mediaType: "application/xml",
+			want: `<?xml version="1.0"?>
+<!-- c -->
+<soap:Envelope xmlns:soap="http://x">
+  <soap:Body>
+    <a id="1">text</a>
+    <b></b>
+  </soap:Body>
+</soap:Envelope>`,
+		},
+		{
+			name: "html keeps preformatted content",
+			body: `<!DOCTYPE html><html><head><title>Hi</title></head><body><div><p>one</p><br><pre>  a
+  b</pre></div></body></html>`,
+			mediaType: "text/html",
+			want: `<!DOCTYPE html>
+<html>
+  <head>
+    <title>Hi</title>
+  </head>
+  <body>
+    <div>
+      <p>one</p>
+      <br>
+      <pre>  a
+  b</pre>
+    </div>
+  </body>
+</html>`,
+		},
+		{"invalid json is kept", `{"a":`, "application/json", PrettyOptions{}, `{"a":`},
+		{"trailing data is kept", `{"a":1} x`, "application/json", PrettyOptions{SortKeys: true}, `{"a":1} x`},
+		{"other media is kept", "plain  text", "text/plain", PrettyOptions{}, "plain  text"},
+		{"empty body", "  ", "application/json", PrettyOptions{}, "  "},
+	}
+	for _, tt := range tests {
+		if got := string(PrettyBody([]byte(tt.body), tt.mediaType, tt.opts)); got != tt.want {
+			t.Errorf("%s: PrettyBody =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package utils
 
 import (
-	"compress/gzip"
 	"fmt"
 	"io"
 	"log"
@@ -56,7 +55,9 @@ func MergeMaps[K comparable](dest, src map[K]interface{}) {
 	}
 }
 
-func GetNestedValue(data map[string]interface{}, keys string) (interface{}, bool) {
+// GetNestedValue returns value of the bracket path eg. [data][items][0], data
+// can be a map, a list or any value decoded from json.
+func GetNestedValue(data interface{}, keys string) (interface{}, bool) {
 	parts := strings.Split(keys, "][")
 	parts[0] = strings.TrimPrefix(parts[0], "[")
 	parts[len(parts)-1] = strings.TrimSuffix(parts[len(parts)-1], "]")
@@ -90,29 +91,22 @@ func HeaderToMap(header http.Header) map[string]interface{} {
 	return result
 }
 
+// ReadBody reads the response body and decodes it with its Content-Encoding.
 func ReadBody(res *http.Response) ([]byte, error) {
-	var reader io.ReadCloser
-	var err error
-
-	switch res.Header.Get("Content-Encoding") {
-	case "gzip":
-		{
-			reader, err = gzip.NewReader(res.Body)
-			if err != nil {
-				return nil, fmt.Errorf("error creating gzip reader : %v", err)
-			}
-		}
-	default:
-		{
-			reader = res.Body
-		}
+	raw, err := ReadRawBody(res)
+	if err != nil || !HasBody(res) {
+		return raw, err
 	}
+	return DecodeBody(raw, ContentEncodings(res.Header))
+}
 
-	body, err := io.ReadAll(reader)
+// ReadRawBody reads the response body as it is sent by the server.
+func ReadRawBody(res *http.Response) ([]byte, error) {
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
 	if err != nil {
 		return nil, fmt.Errorf("error reading response body : %v", err)
 	}
-
 	return body, nil
 }
 
@@ -133,7 +127,7 @@ func LoadWithYaml(configPath string, receiver interface{}) error {
 func ReadFile(path string) ([]byte, error) {
 	file, err := os.Open(path)
 	if err != nil {
-		fmt.Println("Error opening file", err)
+		fmt.Fprintln(os.Stderr, "Error opening file", err)
 		return nil, err
 	}
 	defer file.Close()